@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"path"
+	"sync"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// extractParallel extracts several books with up to jobs at the same time. Every book gets its own Extractor and is
+// exported into <OutDir>/<book>/. The stats of all books are summed up and returned with the number of books that
+// could not be extracted.
+func extractParallel(filePaths []string, jobs int) (playview.Stats, int) {
+	// The hooks of the CLI are shared by all books.
+	var hookLock sync.Mutex
+	onPage := Options.OnPage
+	if onPage != nil {
+		Options.OnPage = func(pageName string, img image.Image) {
+			hookLock.Lock()
+			defer hookLock.Unlock()
+			onPage(pageName, img)
+		}
+	}
+
+	folders := bookFolders(filePaths)
+
+	var lock sync.Mutex
+	total := playview.Stats{ProducedPages: map[string]bool{}}
+	failed := 0
+	done := 0
+
+	var wait sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+	for n, filePath := range filePaths {
+		options := Options
+		options.OutDir = path.Join(Options.OutDir, folders[n])
+		options.NumberPages = Options.Order == "name"
+		if options.OnPage != nil {
+			// Keep the book in the name of the page.
+			options.OnPage = func(pageName string, img image.Image) {
+				Options.OnPage(path.Join(folders[n], pageName), img)
+			}
+		}
+
+		wait.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wait.Done()
+			defer func() { <-slots }()
+
+			extractor := playview.New(options)
+			err := extractor.ExtractFile(filePath)
+
+			lock.Lock()
+			defer lock.Unlock()
+			total.Add(extractor.Stats)
+			done++
+			if err != nil {
+				failed++
+				logExtractionError(fmt.Errorf("unable to extract %v: %w", filePath, err))
+			}
+			logProgress(" >> [%v/%v] books done, %v pages exported", done, len(filePaths), total.ExportedPages)
+		}()
+	}
+	wait.Wait()
+
+	return total, failed
+}
+
+// bookFolders names the output folder of each book, numbering books with the same name.
+func bookFolders(filePaths []string) []string {
+	folders := make([]string, len(filePaths))
+	used := map[string]int{}
+	for n, filePath := range filePaths {
+		name := playview.BookName(filePath)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%v_%v", name, used[name])
+		}
+		folders[n] = name
+	}
+	return folders
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadConfig applies a config file to the flags of a flag set that were not given on the command line (used by
+// -config).
+//
+// The file uses a flat subset of TOML: one `flag = value` per line with the flag names as keys, e.g.
+//
+//	out = "pages"
+//	rotate = 90
+//	sidecar = true
+//
+// Strings are quoted, booleans and numbers are not. Lines starting with # are comments.
+func loadConfig(flags *flag.FlagSet, filePath string) error {
+	handle, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open config: %v", err)
+	}
+	defer handle.Close()
+
+	// Flags on the command line win over the file.
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	scanner := bufio.NewScanner(handle)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, raw, found := strings.Cut(text, "=")
+		if !found {
+			return fmt.Errorf("line %v of config: expected key = value", line)
+		}
+		key = strings.TrimSpace(key)
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("line %v of config: %v", line, err)
+		}
+
+		if key == "config" || flags.Lookup(key) == nil {
+			return fmt.Errorf("line %v of config: unknown option %v", line, key)
+		}
+		if given[key] {
+			continue
+		}
+		err = flags.Set(key, value)
+		if err != nil {
+			return fmt.Errorf("line %v of config: invalid value for %v: %v", line, key, err)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("unable to read config: %v", err)
+	}
+	return nil
+}
+
+// parseConfigValue turns a TOML value into the text the flag package expects.
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		// Basic strings use the same escapes as Go, anything after the closing quote has to be a comment.
+		end := 1
+		for end < len(raw) && raw[end] != '"' {
+			if raw[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(raw) {
+			return "", fmt.Errorf("unterminated string %v", raw)
+		}
+		err := checkTrailingComment(raw[end+1:])
+		if err != nil {
+			return "", err
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		// Literal strings have no escapes.
+		end := strings.Index(raw[1:], "'")
+		if end == -1 {
+			return "", fmt.Errorf("unterminated string %v", raw)
+		}
+		err := checkTrailingComment(raw[end+2:])
+		if err != nil {
+			return "", err
+		}
+		return raw[1 : end+1], nil
+	}
+
+	value, _, _ := strings.Cut(raw, "#")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return value, nil
+}
+
+// checkTrailingComment checks that only a comment follows a value.
+func checkTrailingComment(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %v after value", rest)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: `"pages"`, want: "pages"},
+		{raw: `"a \"quoted\" dir"`, want: `a "quoted" dir`},
+		{raw: `"tab\there"`, want: "tab\there"},
+		{raw: `"out" # comment`, want: "out"},
+		{raw: `"a # b"`, want: "a # b"},
+		{raw: `'C:\books\ocean'`, want: `C:\books\ocean`},
+		{raw: `'a # b' # comment`, want: "a # b"},
+		{raw: `true`, want: "true"},
+		{raw: `90 # degrees`, want: "90"},
+		{raw: `"unterminated`, wantErr: true},
+		{raw: `'unterminated`, wantErr: true},
+		{raw: `"out" trailing`, wantErr: true},
+		{raw: `# only a comment`, wantErr: true},
+		{raw: ``, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseConfigValue(test.raw)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseConfigValue(%q) = %q, expected an error", test.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseConfigValue(%q) failed: %v", test.raw, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseConfigValue(%q) = %q, expected %q", test.raw, got, test.want)
+		}
+	}
+}
+
+// writeConfig stores a config file in a temporary folder.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "playview.toml")
+	err := os.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+	return filePath
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	out := flags.String("out", "out", "")
+	rotate := flags.Int("rotate", 0, "")
+	sidecar := flags.Bool("sidecar", false, "")
+
+	err := flags.Parse([]string{"-rotate", "180"})
+	if err != nil {
+		t.Fatalf("unable to parse flags: %v", err)
+	}
+
+	filePath := writeConfig(t, "# routine job\nout = \"pages\"\n\nrotate = 90\nsidecar = true\n")
+	err = loadConfig(flags, filePath)
+	if err != nil {
+		t.Fatalf("unable to load config: %v", err)
+	}
+
+	if *out != "pages" {
+		t.Errorf("out = %q, expected the value of the file", *out)
+	}
+	if *rotate != 180 {
+		t.Errorf("rotate = %v, expected the value of the command line", *rotate)
+	}
+	if !*sidecar {
+		t.Errorf("sidecar not set by the file")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []string{
+		"bogus = 1\n",
+		"rotate = x\n",
+		"rotate\n",
+		"config = \"other.toml\"\n",
+	}
+	for _, content := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.Int("rotate", 0, "")
+		flags.String("config", "", "")
+
+		err := loadConfig(flags, writeConfig(t, content))
+		if err == nil {
+			t.Errorf("loadConfig(%q) succeeded, expected an error", content)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// Size of a page thumbnail on the contact sheet.
+const thumbnailSize = 160
+
+// Space around each thumbnail and the scale of the caption font.
+const contactSheetPadding = 8
+const captionScale = 2
+
+type thumbnail struct {
+	name string
+	img  image.Image
+}
+
+// Thumbnails of the merged pages (used by -contact-sheet).
+var thumbnails []thumbnail
+
+// addThumbnail keeps a downsized copy of a merged page for the contact sheet.
+func addThumbnail(name string, img image.Image) {
+	width, height := playview.FitSize(img.Bounds().Dx(), img.Bounds().Dy(), thumbnailSize, thumbnailSize)
+	thumbnails = append(thumbnails, thumbnail{name: name, img: playview.ResampleImage(img, width, height, Options.Resample)})
+}
+
+// writeContactSheet tiles all thumbnails with captions into a single png.
+func writeContactSheet(filePath string, columns int) error {
+	if len(thumbnails) == 0 {
+		log.Printf("  [WARNING] No pages for the contact sheet.")
+		return nil
+	}
+
+	columns = max(1, min(columns, len(thumbnails)))
+	rows := (len(thumbnails) + columns - 1) / columns
+
+	captionHeight := (playview.GlyphHeight + 2) * captionScale
+	cellWidth := thumbnailSize + 2*contactSheetPadding
+	cellHeight := thumbnailSize + captionHeight + 2*contactSheetPadding
+
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*cellWidth, rows*cellHeight))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	for n, thumb := range thumbnails {
+		x := (n%columns)*cellWidth + contactSheetPadding
+		y := (n/columns)*cellHeight + contactSheetPadding
+
+		// Center the thumbnail within its cell.
+		bounds := thumb.img.Bounds()
+		offset := image.Pt(x+(thumbnailSize-bounds.Dx())/2, y+(thumbnailSize-bounds.Dy())/2)
+		draw.Draw(sheet, bounds.Add(offset), thumb.img, bounds.Min, draw.Over)
+
+		// Shorten the caption to the width of the cell.
+		caption := []rune(thumb.name)
+		for len(caption) > 0 && playview.TextWidth(string(caption), captionScale) > thumbnailSize {
+			caption = caption[:len(caption)-1]
+		}
+		playview.DrawText(sheet, x, y+thumbnailSize+captionScale, string(caption), captionScale, color.Black)
+	}
+
+	sheetFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %v", err)
+	}
+	err = png.Encode(sheetFile, sheet)
+	if err != nil {
+		_ = sheetFile.Close()
+		return fmt.Errorf("unable to encode png: %v", err)
+	}
+	closeErr := sheetFile.Close()
+	if closeErr != nil {
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+
+	logProgress(" >> Contact sheet with %v pages written to %v", len(thumbnails), filePath)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// diffFiles compares the structure of two files page by page and returns the number of differences.
+func diffFiles(filePathA string, filePathB string) (int, error) {
+	extractor := playview.New(Options)
+
+	pagesA, err := extractor.ReadStructure(filePathA)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read %v: %v", filePathA, err)
+	}
+
+	pagesB, err := extractor.ReadStructure(filePathB)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read %v: %v", filePathB, err)
+	}
+
+	byName := map[string]playview.PageInfo{}
+	for _, page := range pagesB {
+		byName[page.FileName] = page
+	}
+
+	differences := 0
+	report := func(format string, v ...any) {
+		differences++
+		log.Printf("  [DIFF] "+format, v...)
+	}
+
+	for _, a := range pagesA {
+		b, exists := byName[a.FileName]
+		if !exists {
+			report("[%v] missing in %v", a.FileName, filePathB)
+			continue
+		}
+		delete(byName, a.FileName)
+
+		// Damaged pages cannot be compared any further.
+		if a.ReadError != nil || b.ReadError != nil {
+			if a.ReadError != nil {
+				report("[%v] unreadable in %v: %v", a.FileName, filePathA, a.ReadError)
+			}
+			if b.ReadError != nil {
+				report("[%v] unreadable in %v: %v", b.FileName, filePathB, b.ReadError)
+			}
+			continue
+		}
+
+		if a.ImageType != b.ImageType {
+			report("[%v] type %v <> %v", a.FileName, a.ImageType, b.ImageType)
+		}
+		if a.ImageWidth != b.ImageWidth || a.ImageHeight != b.ImageHeight {
+			report("[%v] dimensions %vx%v <> %vx%v", a.FileName, a.ImageWidth, a.ImageHeight, b.ImageWidth, b.ImageHeight)
+		}
+		if len(a.Images) != len(b.Images) {
+			report("[%v] tiles %v <> %v", a.FileName, len(a.Images), len(b.Images))
+		}
+		if layersA, layersB := a.Layers(), b.Layers(); !slices.Equal(layersA, layersB) {
+			report("[%v] layers %v <> %v", a.FileName, layersA, layersB)
+		}
+	}
+
+	// Everything left was not part of the first file.
+	for _, b := range pagesB {
+		if _, exists := byName[b.FileName]; exists {
+			report("[%v] missing in %v", b.FileName, filePathA)
+		}
+	}
+
+	return differences, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"slices"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// sizeEstimate is the projected output of an extraction (used by -estimate).
+type sizeEstimate struct {
+	pages  int
+	images int
+	tiles  int
+
+	// Uncompressed size of the merged images.
+	imageBytes int64
+
+	// Embedded (jpeg) data of the exported tiles, and their uncompressed size.
+	tileDataBytes  int64
+	tilePixelBytes int64
+}
+
+// estimateFile parses the structure of a file and projects the size of its output with the current options.
+func estimateFile(filePath string) (sizeEstimate, error) {
+	extractor := playview.New(Options)
+	pages, err := extractor.ReadStructure(filePath)
+	if err != nil {
+		return sizeEstimate{}, fmt.Errorf("unable to read %v: %v", filePath, err)
+	}
+
+	bytesPerPixel := int64(4)
+	if Options.BitDepth == 16 {
+		bytesPerPixel = 8
+	}
+	exportTiles := !Options.MergeImages || Options.ExportTiles
+
+	estimate := sizeEstimate{}
+	for _, page := range pages {
+		if !Options.IsTargetPage(page.FileName) || (Options.DualOnly && page.ImageType != "gvmp") {
+			continue
+		}
+		if page.ReadError != nil {
+			log.Printf("  [WARNING] Page [%v] is not estimated: %v", page.FileName, page.ReadError)
+			continue
+		}
+
+		// The same layer as exported, pages without it export nothing.
+		layers := page.Layers()
+		pageLayer := Options.TargetLayer
+		if Options.ThumbsOnly {
+			layer, found := playview.ThumbnailLayer(page)
+			if !found {
+				continue
+			}
+			pageLayer = layer
+		}
+		if pageLayer != -1 && !Options.FillFromLayer && !slices.Contains(layers, pageLayer) {
+			continue
+		}
+		estimate.pages++
+
+		if exportTiles {
+			for _, img := range page.Images {
+				if pageLayer == -1 || img.Layer == pageLayer {
+					estimate.tiles++
+					estimate.tileDataBytes += int64(img.FileLength)
+					if Options.RawTiles {
+						estimate.tilePixelBytes += int64(img.FileLength)
+					} else {
+						estimate.tilePixelBytes += int64(img.Width) * int64(img.Height) * bytesPerPixel
+					}
+				}
+			}
+		}
+
+		if !Options.MergeImages {
+			continue
+		}
+
+		// One image per page, or one per layer.
+		images := 1
+		if Options.SeparateLayers || Options.ZoomAnimation || Options.AlignLayers {
+			images = len(layers)
+		}
+
+		size := image.Pt(page.ImageWidth, page.ImageHeight)
+		if !Options.Clip.Empty() {
+			size = Options.Clip.Intersect(image.Rect(0, 0, page.ImageWidth, page.ImageHeight)).Size()
+		}
+		if Options.RotateDegrees == 90 || Options.RotateDegrees == 270 {
+			size = image.Pt(size.Y, size.X)
+		}
+		if Options.PreviewWidth > 0 && size.X > Options.PreviewWidth {
+			size = image.Pt(Options.PreviewWidth, max(1, size.Y*Options.PreviewWidth/size.X))
+		}
+		if Options.MaxMegapixels > 0 {
+			if width, height, scaled := playview.MegapixelSize(size.X, size.Y, Options.MaxMegapixels); scaled {
+				size = image.Pt(width, height)
+			}
+		}
+		if Options.FitWidth > 0 {
+			size = image.Pt(Options.FitWidth, Options.FitHeight)
+		}
+
+		estimate.images += images
+		estimate.imageBytes += int64(images) * int64(size.X) * int64(size.Y) * bytesPerPixel
+	}
+
+	return estimate, nil
+}
+
+// logEstimate prints the projected output of all files.
+func logEstimate(filePaths []string) error {
+	total := sizeEstimate{}
+	for _, filePath := range filePaths {
+		estimate, err := estimateFile(filePath)
+		if err != nil {
+			return err
+		}
+		log.Printf("  > [%v] %v pages, %v merged images (%v), %v tiles (%v of data, %v uncompressed)", filePath, estimate.pages, estimate.images, formatBytes(estimate.imageBytes), estimate.tiles, formatBytes(estimate.tileDataBytes), formatBytes(estimate.tilePixelBytes))
+		total.pages += estimate.pages
+		total.images += estimate.images
+		total.tiles += estimate.tiles
+		total.imageBytes += estimate.imageBytes
+		total.tileDataBytes += estimate.tileDataBytes
+		total.tilePixelBytes += estimate.tilePixelBytes
+	}
+
+	// PNG compresses, so the uncompressed size is an upper bound. Pixel dumps (-pixels) have exactly this size.
+	projected := total.imageBytes + total.tilePixelBytes
+	log.Printf(" >> Projected output: up to %v for %v pages (%v merged images, %v tiles)", formatBytes(projected), total.pages, total.images, total.tiles)
+	return nil
+}
+
+// formatBytes prints a size in bytes with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%v B", n)
+	}
+	value, exponent := float64(n)/unit, 0
+	for value >= unit && exponent < 4 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exponent])
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// File in the output describing how it was produced (used by -extraction-info).
+const extractionInfoName = "EXTRACTION_INFO.txt"
+
+// toolVersion returns the module version and the commit the extractor was built from, as far as they are known.
+func toolVersion() string {
+	info, found := debug.ReadBuildInfo()
+	if !found {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			version += " " + setting.Value
+		}
+		if setting.Key == "vcs.modified" && setting.Value == "true" {
+			version += " (modified)"
+		}
+	}
+	return version
+}
+
+// writeExtractionInfo writes the version, the command line, the input files with their SHA-256 and the counts of the
+// summary of a run.
+func writeExtractionInfo(w io.Writer, started time.Time, filePaths []string, stats playview.Stats) error {
+	var info strings.Builder
+	fmt.Fprintf(&info, "Extracted with playview-extractor %v\n", toolVersion())
+	fmt.Fprintf(&info, "Date: %v\n", started.Format(time.RFC3339))
+	fmt.Fprintf(&info, "Command: %v\n", strings.Join(os.Args, " "))
+
+	fmt.Fprintf(&info, "\nFlags:\n")
+	flag.Visit(func(f *flag.Flag) {
+		fmt.Fprintf(&info, "  -%v=%v\n", f.Name, f.Value)
+	})
+
+	fmt.Fprintf(&info, "\nSources:\n")
+	for _, filePath := range filePaths {
+		hash := stats.SourceHashes[filePath]
+		if hash == "" {
+			hash = "(not hashed)"
+		}
+		fmt.Fprintf(&info, "  %v  %v\n", hash, filePath)
+	}
+
+	fmt.Fprintf(&info, "\nPages: %v exported, %v failed, %v crashed\n", stats.ExportedPages, stats.FailedPages, len(stats.CrashedPages))
+	fmt.Fprintf(&info, "Tiles: %v decoded, %v undecodable\n", stats.DecodedTiles, stats.FailedTiles)
+	fmt.Fprintf(&info, "Warnings: %v\n", stats.Warnings)
+
+	_, err := io.WriteString(w, info.String())
+	if err != nil {
+		return fmt.Errorf("unable to write extraction info: %v", err)
+	}
+	return nil
+}
+
+// saveExtractionInfo stores the extraction info next to the images of a run.
+func saveExtractionInfo(output playview.Output, started time.Time, filePaths []string, stats playview.Stats) error {
+	if output == nil {
+		output = playview.FileOutput{Dir: Options.OutDir}
+	}
+	w, err := output.Create(extractionInfoName)
+	if err != nil {
+		return err
+	}
+	err = writeExtractionInfo(w, started, filePaths, stats)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+func TestWriteExtractionInfo(t *testing.T) {
+	stats := playview.Stats{
+		DecodedTiles:  10,
+		FailedTiles:   1,
+		FailedPages:   1,
+		ExportedPages: 4,
+		SourceHashes:  map[string]string{"ocean/gvd.dat": "b43ea40f"},
+	}
+	started := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	var info strings.Builder
+	err := writeExtractionInfo(&info, started, []string{"ocean/gvd.dat", "desert/gvd.dat"}, stats)
+	if err != nil {
+		t.Fatalf("unable to write extraction info: %v", err)
+	}
+
+	for _, want := range []string{
+		"Date: 2024-05-01T12:30:00Z\n",
+		"  b43ea40f  ocean/gvd.dat\n",
+		"  (not hashed)  desert/gvd.dat\n",
+		"Pages: 4 exported, 1 failed, 0 crashed\n",
+		"Tiles: 10 decoded, 1 undecodable\n",
+	} {
+		if !strings.Contains(info.String(), want) {
+			t.Errorf("extraction info is missing %q:\n%v", want, info.String())
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package playview
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"io"
+)
+
+// writeAPNG encodes equally sized frames as an animated PNG that loops forever.
+//
+// The frames are always stored as 8 bit RGBA, which keeps every frame compatible with the shared IHDR chunk. Each
+// frame is shown for delayMs milliseconds.
+func writeAPNG(w io.Writer, frames []image.Image, delayMs int) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames")
+	}
+
+	bounds := frames[0].Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	_, err := w.Write([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A})
+	if err != nil {
+		return err
+	}
+
+	// Width, height, bit depth 8, color type 6 (RGBA), default compression, filter and no interlace.
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8
+	ihdr[9] = 6
+	err = writeChunk(w, "IHDR", ihdr)
+	if err != nil {
+		return err
+	}
+
+	// Number of frames and plays (0 loops forever).
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	err = writeChunk(w, "acTL", actl)
+	if err != nil {
+		return err
+	}
+
+	sequence := uint32(0)
+	for n, frame := range frames {
+		if frame.Bounds().Dx() != width || frame.Bounds().Dy() != height {
+			return fmt.Errorf("frame %v has a different size", n)
+		}
+
+		// Sequence, size, offset, delay numerator and denominator, dispose and blend operation.
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(height))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delayMs))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		sequence++
+		err = writeChunk(w, "fcTL", fctl)
+		if err != nil {
+			return err
+		}
+
+		data, err := compressPixels(frame)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			// The first frame doubles as the still image.
+			err = writeChunk(w, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, sequence)
+			sequence++
+			err = writeChunk(w, "fdAT", append(fdat, data...))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeChunk(w, "IEND", nil)
+}
+
+// compressPixels returns the zlib compressed RGBA scanlines of an image (without filtering).
+func compressPixels(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 1+4*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			offset := 1 + 4*(x-bounds.Min.X)
+			row[offset], row[offset+1], row[offset+2], row[offset+3] = c.R, c.G, c.B, c.A
+		}
+		_, err := zw.Write(row)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeChunk writes a single PNG chunk: length, type, data and checksum.
+func writeChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, part := range [][]byte{header, data, footer} {
+		_, err := w.Write(part)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package playview
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// input is a seekable gvd.dat, either a plain file, an entry of a zip archive or a remote file.
+type input interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// archiveEntry is a zip entry (or a remote file) together with what has to be closed with it.
+type archiveEntry struct {
+	io.ReadSeeker
+	io.Closer
+}
+
+// openInput opens a gvd.dat, the entry of a zip archive given as "archive.zip:path/in/archive/gvd.dat", or an http(s)
+// URL.
+func (e *Extractor) openInput(filePath string) (input, error) {
+	if isRemotePath(filePath) {
+		return e.openRemote(filePath)
+	}
+
+	archivePath, entryName, isArchive := splitArchivePath(filePath)
+	if !isArchive {
+		return os.Open(filePath)
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := e.openArchiveEntry(archive, entryName)
+	if err != nil {
+		_ = archive.Close()
+		return nil, fmt.Errorf("unable to open %v in %v: %v", entryName, archivePath, err)
+	}
+
+	return archiveEntry{ReadSeeker: entry, Closer: archive}, nil
+}
+
+// splitArchivePath splits "archive.zip:entry" into the path of the archive and the name of the entry.
+func splitArchivePath(filePath string) (string, string, bool) {
+	index := strings.Index(strings.ToLower(filePath), ".zip:")
+	if index == -1 {
+		return "", "", false
+	}
+	return filePath[:index+len(".zip")], filePath[index+len(".zip:"):], true
+}
+
+// openArchiveEntry finds an entry in a zip archive.
+//
+// The parser needs to seek, so stored entries are read in place and compressed entries are buffered in memory.
+func (e *Extractor) openArchiveEntry(archive *os.File, entryName string) (io.ReadSeeker, error) {
+	info, err := archive.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(archive, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != entryName {
+			continue
+		}
+
+		if file.Method == zip.Store {
+			offset, err := file.DataOffset()
+			if err != nil {
+				return nil, err
+			}
+			return io.NewSectionReader(archive, offset, int64(file.UncompressedSize64)), nil
+		}
+
+		e.logf("Buffering %v (%v bytes)", entryName, file.UncompressedSize64)
+
+		entry, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer entry.Close()
+
+		raw, err := io.ReadAll(entry)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(raw), nil
+	}
+
+	return nil, fmt.Errorf("entry not found")
+}
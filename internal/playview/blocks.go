@@ -0,0 +1,126 @@
+package playview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// Marker in front of every section of a page database.
+var blockMarker = []byte{0x42, 0x4C, 0x4B, 0x5F}
+
+// dumpBlocks stores every BLK_ section of the database of page i as <OutDir>/<page>_blk_<n>.bin (used by -dump-blocks).
+//
+// A section runs from its marker to the next marker found after its declared length, so bytes not covered by the
+// declared length (like the entry sizes of the image table) stay with their section.
+func (f *File) dumpBlocks(i int) error {
+	raw, start, err := f.readDatabaseViewer(i)
+	if err != nil {
+		return err
+	}
+
+	pos := bytes.Index(raw, blockMarker)
+	if pos == -1 {
+		f.warnf("Database of page %v has no BLK_ sections.", i)
+		return nil
+	}
+	f.logf("   .. Head [%v bytes]", pos)
+
+	for block := 0; pos != -1; block++ {
+		declared := int64(-1)
+		if pos+8 <= len(raw) {
+			declared = int64(binary.BigEndian.Uint32(raw[pos+4 : pos+8]))
+		}
+
+		end := len(raw)
+		if searchFrom := int64(pos) + 8 + max(declared, 0); searchFrom < int64(len(raw)) {
+			if next := bytes.Index(raw[searchFrom:], blockMarker); next != -1 {
+				end = int(searchFrom) + next
+			}
+		}
+
+		f.logf("   .. Block %v at 0x%X [%v bytes, declared %v]", block, start+int64(pos), end-pos, declared)
+
+		if !f.ValidateOnly {
+			err := f.writeBlock(path.Join(f.OutDir, fmt.Sprintf("%v_blk_%v.bin", f.Pages[i].OutputName, block)), raw[pos:end])
+			if err != nil {
+				return err
+			}
+		}
+
+		if end == len(raw) {
+			break
+		}
+		pos = end
+	}
+
+	return nil
+}
+
+// dumpDatabaseViewer stores the database of page i verbatim as <OutDir>/<page>.dbv, before it is parsed (used by
+// -dump-dbviewer).
+func (f *File) dumpDatabaseViewer(i int) error {
+	raw, start, err := f.readDatabaseViewer(i)
+	if err != nil {
+		return err
+	}
+
+	name := f.Pages[i].OutputName
+	if name == "" {
+		name = f.Pages[i].FileName
+	}
+	f.logf("   .. Database at 0x%X [%v bytes]", start, len(raw))
+
+	if f.ValidateOnly {
+		return nil
+	}
+	return f.writeBlock(path.Join(f.OutDir, fmt.Sprintf("%v.dbv", name)), raw)
+}
+
+// readDatabaseViewer reads the whole database of page i as listed in the page table and returns it with its offset.
+// A database reaching beyond the end of the file is returned as far as it exists. The parser continues where it was.
+func (f *File) readDatabaseViewer(i int) ([]byte, int64, error) {
+	resume, _ := f.handle.Seek(0, 1)
+	defer f.handle.Seek(resume, 0)
+
+	// A misread length must not allocate more than is left of the file.
+	size, err := f.handle.Seek(0, 2)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to seek: %v", err)
+	}
+	start := f.base + f.totalLengthFirstPart + f.Pages[i].OffsetDataBaseViewer
+	length := f.Pages[i].LengthDataBaseViewer
+	if available := max(size-start, 0); length > available {
+		f.warnf("Database of page %v is truncated at %v of %v bytes.", i, available, length)
+		length = available
+	}
+
+	_, err = f.handle.Seek(start, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to seek: %v", err)
+	}
+	raw := make([]byte, length)
+	n, err := io.ReadFull(f.handle, raw)
+	if err != nil {
+		f.warnf("Database of page %v is truncated at %v of %v bytes.", i, n, len(raw))
+	}
+	return raw[:n], start, nil
+}
+
+// writeBlock stores the data of a section within a write slot.
+func (f *File) writeBlock(filePath string, data []byte) error {
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer f.acquireWrite()()
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write block: %v", err)
+	}
+	return nil
+}
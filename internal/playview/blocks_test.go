@@ -0,0 +1,44 @@
+package playview
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDumpDatabaseViewer(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	outDir := t.TempDir()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	f, err := New(Options{OutDir: outDir, DumpDatabaseViewer: true}).Open(filePath)
+	if err != nil {
+		t.Fatalf("unable to open book: %v", err)
+	}
+	defer f.Close()
+
+	err = f.ExportPage(0)
+	if err != nil {
+		t.Fatalf("unable to export page: %v", err)
+	}
+
+	dumped, err := os.ReadFile(filepath.Join(outDir, f.Pages[0].FileName+".dbv"))
+	if err != nil {
+		t.Fatalf("unable to read dump: %v", err)
+	}
+	book, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	start := f.totalLengthFirstPart + f.Pages[0].OffsetDataBaseViewer
+	if want := book[start : start+f.Pages[0].LengthDataBaseViewer]; !bytes.Equal(dumped, want) {
+		t.Errorf("dumped %v bytes, expected the %v bytes of the database", len(dumped), len(want))
+	}
+	if !bytes.HasPrefix(dumped, []byte("GVEW0100JPEG0100")) {
+		t.Errorf("dump starts with %q", dumped[:min(len(dumped), 16)])
+	}
+}
@@ -0,0 +1,33 @@
+package playview
+
+import (
+	"image"
+	"sync"
+)
+
+// Pools of merged images by size. Pages of a book mostly share a few sizes, so reusing their canvas spares the garbage
+// collector a full page of pixels per page.
+var canvasPools sync.Map
+
+// newCanvas returns a transparent image of the given size, reused from an earlier page if possible.
+func newCanvas(width int, height int) *image.RGBA {
+	if canvas, ok := canvasPool(image.Pt(width, height)).Get().(*image.RGBA); ok {
+		clear(canvas.Pix)
+		return canvas
+	}
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+// recycleCanvas returns an image created by newCanvas to its pool, it must not be used afterwards.
+func recycleCanvas(canvas *image.RGBA) {
+	canvasPool(canvas.Bounds().Size()).Put(canvas)
+}
+
+// canvasPool returns the pool of images of the given size.
+func canvasPool(size image.Point) *sync.Pool {
+	if pool, exists := canvasPools.Load(size); exists {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := canvasPools.LoadOrStore(size, &sync.Pool{})
+	return pool.(*sync.Pool)
+}
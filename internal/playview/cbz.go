@@ -0,0 +1,68 @@
+package playview
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ZipOutput collects the files of an extraction in a zip archive, like a CBZ comic book (used by -out book.cbz). The
+// files are stored without compression, as png is compressed already. The archive is written as <name>.part and only
+// moved to its name once its directory is complete, so an interrupted run never leaves an archive that does not open.
+type ZipOutput struct {
+	file    *partFile
+	archive *zip.Writer
+
+	// Only a single entry can be written at a time.
+	mu sync.Mutex
+}
+
+// CreateZipOutput creates the archive at filePath. Close has to be called to complete it.
+func CreateZipOutput(filePath string) (*ZipOutput, error) {
+	file, err := createPartFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &ZipOutput{file: file, archive: zip.NewWriter(file)}, nil
+}
+
+func (o *ZipOutput) Create(name string) (OutputWriter, error) {
+	return &zipEntry{output: o, name: name}, nil
+}
+
+// Close writes the directory of the archive and moves it to its name.
+func (o *ZipOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	err := o.archive.Close()
+	if err != nil {
+		o.file.Abort()
+		return fmt.Errorf("unable to write archive: %v", err)
+	}
+	return o.file.Close()
+}
+
+// zipEntry buffers a file, so encoding several pages at the same time does not mix up their entries.
+type zipEntry struct {
+	bytes.Buffer
+	output *ZipOutput
+	name   string
+}
+
+func (e *zipEntry) Close() error {
+	e.output.mu.Lock()
+	defer e.output.mu.Unlock()
+	w, err := e.output.archive.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("unable to add %v to archive: %v", e.name, err)
+	}
+	_, err = w.Write(e.Bytes())
+	if err != nil {
+		return fmt.Errorf("unable to write %v to archive: %v", e.name, err)
+	}
+	return nil
+}
+
+func (e *zipEntry) Abort() {}
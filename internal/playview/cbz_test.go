@@ -0,0 +1,72 @@
+package playview
+
+import (
+	"archive/zip"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+func TestZipOutput(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "book.cbz")
+	output, err := OpenOutput(filePath)
+	if err != nil {
+		t.Fatalf("unable to open output: %v", err)
+	}
+
+	sink := WriterSink(output, 0)
+	for _, name := range []string{"0001_p001", "0002_p002"} {
+		err := sink(name, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+		if err != nil {
+			t.Fatalf("unable to write %v: %v", name, err)
+		}
+	}
+
+	// The archive only gets its name once it is complete.
+	if _, err := os.Stat(filePath); err == nil {
+		t.Errorf("incomplete archive is already at %v", filePath)
+	}
+	err = output.(*ZipOutput).Close()
+	if err != nil {
+		t.Fatalf("unable to close archive: %v", err)
+	}
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("unable to open archive: %v", err)
+	}
+	defer archive.Close()
+	if _, err := os.Stat(filePath + ".part"); err == nil {
+		t.Errorf("temporary archive is left behind")
+	}
+	var names []string
+	for _, entry := range archive.File {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"0001_p001.png", "0002_p002.png"}; !slices.Equal(names, want) {
+		t.Errorf("archive holds %v, expected %v", names, want)
+	}
+}
+
+func TestMissingLayerIsSkipped(t *testing.T) {
+	filePath := writeTestBook(t, 2, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var pages []string
+	e := New(Options{MergeImages: true, TargetLayer: 1, Sink: func(pageName string, img image.Image) error {
+		pages = append(pages, pageName)
+		return nil
+	}})
+	err := e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if len(pages) != 0 || e.Stats.DecodedTiles != 0 || e.Stats.Warnings != 0 {
+		t.Errorf("got pages %v, %v decoded tiles and %v warnings, expected nothing", pages, e.Stats.DecodedTiles, e.Stats.Warnings)
+	}
+}
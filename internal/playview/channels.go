@@ -0,0 +1,51 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path"
+)
+
+// Suffixes of the channel planes written by writeChannels.
+var channelNames = []string{"r", "g", "b", "a"}
+
+// writeChannels stores the red, green, blue and alpha channel of a merged page as the grayscale images
+// <OutDir>/<name>_<r|g|b|a>.png (used by -split-channels). The color channels are not premultiplied by alpha, 16-bit
+// pages give 16-bit planes.
+func (f *File) writeChannels(name string, img image.Image) error {
+	bounds := img.Bounds()
+	planes := make([]*image.Gray16, len(channelNames))
+	for n := range planes {
+		planes[n] = image.NewGray16(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			for n, value := range []uint16{c.R, c.G, c.B, c.A} {
+				planes[n].SetGray16(x-bounds.Min.X, y-bounds.Min.Y, color.Gray16{Y: value})
+			}
+		}
+	}
+
+	for n, plane := range planes {
+		var planeImage image.Image = plane
+		if f.BitDepth != 16 {
+			planeImage = toGray(plane)
+		}
+		err := f.writeSlotPNG(path.Join(f.OutDir, fmt.Sprintf("%v_%v.png", name, channelNames[n])), planeImage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toGray converts a 16-bit grayscale image to 8 bits.
+func toGray(img *image.Gray16) *image.Gray {
+	gray := image.NewGray(img.Bounds())
+	for n := range gray.Pix {
+		gray.Pix[n] = img.Pix[2*n]
+	}
+	return gray
+}
@@ -0,0 +1,51 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteChannels(t *testing.T) {
+	outDir := t.TempDir()
+	f := &File{Extractor: New(Options{OutDir: outDir})}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF})
+	img.SetRGBA(1, 0, color.RGBA{R: 0x40, G: 0x40, B: 0x00, A: 0x80}) // Premultiplied, 0x7F without alpha.
+
+	err := f.writeChannels("p001", img)
+	if err != nil {
+		t.Fatalf("unable to write channels: %v", err)
+	}
+
+	want := map[string][]uint8{
+		"r": {0x10, 0x7F},
+		"g": {0x20, 0x7F},
+		"b": {0x30, 0x00},
+		"a": {0xFF, 0x80},
+	}
+	for name, values := range want {
+		file, err := os.Open(path.Join(outDir, "p001_"+name+".png"))
+		if err != nil {
+			t.Fatalf("unable to open channel %v: %v", name, err)
+		}
+		plane, err := png.Decode(file)
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("unable to decode channel %v: %v", name, err)
+		}
+		gray, isGray := plane.(*image.Gray)
+		if !isGray {
+			t.Fatalf("channel %v is a %T, expected 8-bit grayscale", name, plane)
+		}
+		for x, value := range values {
+			if got := gray.GrayAt(x, 0).Y; got != value {
+				t.Errorf("channel %v at %v is 0x%02X, expected 0x%02X", name, x, got, value)
+			}
+		}
+	}
+}
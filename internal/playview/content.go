@@ -0,0 +1,52 @@
+package playview
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Page file names as they appear in content.dat.
+var contentPagePattern = regexp.MustCompile(`[A-Za-z0-9_\-]+\.gvd`)
+
+// readContentPages returns the page names referenced by a content.dat, in order of appearance.
+func readContentPages(filePath string) ([]string, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range contentPagePattern.FindAll(raw, -1) {
+		name, _ := strings.CutSuffix(string(match), ".gvd")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// CheckContentPages warns about every page listed in content.dat that did not produce any output.
+func (e *Extractor) CheckContentPages(filePath string) error {
+	names, err := readContentPages(filePath)
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	for _, name := range names {
+		if !e.IsTargetPage(name) {
+			continue
+		}
+		if !e.Stats.ProducedPages[name] {
+			missing++
+			e.warnf("Page %v is listed in %v but has no output.", name, filePath)
+		}
+	}
+
+	e.logf(" >> %v of %v listed pages missing.", missing, len(names))
+
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build turbojpeg
+
+package playview
+
+/*
+#cgo LDFLAGS: -lturbojpeg
+#include <turbojpeg.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+)
+
+func init() {
+	jpegDecoders["turbo"] = decodeTurboJPEG
+}
+
+// decodeTurboJPEG decodes a JPEG tile with libjpeg-turbo.
+func decodeTurboJPEG(data []byte) (image.Image, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no jpeg data")
+	}
+
+	handle := C.tjInitDecompress()
+	if handle == nil {
+		return nil, fmt.Errorf("unable to initialize turbojpeg")
+	}
+	defer C.tjDestroy(handle)
+
+	src := (*C.uchar)(unsafe.Pointer(&data[0]))
+	srcLength := C.ulong(len(data))
+
+	var width, height, subsampling, colorspace C.int
+	if C.tjDecompressHeader3(handle, src, srcLength, &width, &height, &subsampling, &colorspace) != 0 {
+		return nil, fmt.Errorf("unable to read jpeg header: %v", C.GoString(C.tjGetErrorStr2(handle)))
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("image has no pixels (%vx%v)", width, height)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	dst := (*C.uchar)(unsafe.Pointer(&img.Pix[0]))
+	if C.tjDecompress2(handle, src, srcLength, dst, width, C.int(img.Stride), height, C.TJPF_RGBA, 0) != 0 {
+		return nil, fmt.Errorf("unable to decode jpeg: %v", C.GoString(C.tjGetErrorStr2(handle)))
+	}
+
+	return img, nil
+}
@@ -0,0 +1,36 @@
+//go:build turbojpeg
+
+package playview
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestTurboJPEGMatchesStd(t *testing.T) {
+	data := testJPEG(t)
+	std, err := decodeStdJPEG(data)
+	if err != nil {
+		t.Fatalf("unable to decode with std: %v", err)
+	}
+	turbo, err := decodeTurboJPEG(data)
+	if err != nil {
+		t.Fatalf("unable to decode with turbo: %v", err)
+	}
+	if std.Bounds() != turbo.Bounds() {
+		t.Fatalf("bounds differ: %v <> %v", std.Bounds(), turbo.Bounds())
+	}
+
+	// Both follow the same standard, but may round differently.
+	for _, p := range [][2]int{{0, 0}, {100, 50}, {255, 255}} {
+		a := color.RGBAModel.Convert(std.At(p[0], p[1])).(color.RGBA)
+		b := color.RGBAModel.Convert(turbo.At(p[0], p[1])).(color.RGBA)
+		if diff(a.R, b.R) > 8 || diff(a.G, b.G) > 8 || diff(a.B, b.B) > 8 {
+			t.Errorf("pixel %v differs: %v <> %v", p, a, b)
+		}
+	}
+}
+
+func diff(a uint8, b uint8) int {
+	return max(int(a), int(b)) - min(int(a), int(b))
+}
@@ -0,0 +1,190 @@
+package playview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+// Decoder turns the data of a single tile into an image. The data is reused for the next tile, so the image must not
+// refer to it.
+type Decoder func(data []byte) (image.Image, error)
+
+type registeredDecoder struct {
+	magic  []byte
+	decode func(data []byte, jpegDecoder string) (image.Image, error)
+}
+
+var decoders []registeredDecoder
+
+// JPEG decoders that can be selected with Options.JPEGDecoder. Alternatives register themselves behind build tags.
+var jpegDecoders = map[string]Decoder{
+	"std": decodeStdJPEG,
+}
+
+var jpegMagic = []byte{0xFF, 0xD8}
+
+// RegisterDecoder adds a decoder for tiles starting with the given magic bytes. It takes precedence over the decoders
+// registered before, including the built-in JPEG and PNG decoders.
+func RegisterDecoder(magic []byte, decode Decoder) {
+	decoders = append(decoders, registeredDecoder{magic: magic, decode: func(data []byte, jpegDecoder string) (image.Image, error) {
+		return decode(data)
+	}})
+}
+
+func init() {
+	// JPEG tiles go through the decoder selected by Options.JPEGDecoder.
+	decoders = append(decoders, registeredDecoder{magic: jpegMagic, decode: decodeJPEG})
+	RegisterDecoder([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, func(data []byte) (image.Image, error) {
+		return png.Decode(bytes.NewReader(data))
+	})
+}
+
+// decodeJPEG decodes a JPEG tile with the selected JPEG decoder. If that fails, the tile is decoded a second time with
+// garbage after the end of the image and unknown markers removed, as other tools read such tiles fine.
+func decodeJPEG(data []byte, jpegDecoder string) (image.Image, error) {
+	img, err := jpegDecoders[jpegDecoder](data)
+	if err == nil {
+		return cmykToRGBA(img), nil
+	}
+
+	repaired := repairJPEG(data)
+	if bytes.Equal(repaired, data) {
+		return nil, err
+	}
+	img, repairErr := jpegDecoders[jpegDecoder](repaired)
+	if repairErr != nil {
+		return nil, err
+	}
+	return cmykToRGBA(img), nil
+}
+
+// cmykToRGBA converts the tiles of CMYK JPEGs to RGB right after decoding, so every output (merged pages, tiles,
+// channels, pixel dumps) gets the same colors. The decoder already undoes the inverted channels of Adobe CMYK files,
+// the conversion is the plain one without a color profile (R = (1-C)(1-K) and so on). Other images are returned as
+// they are.
+func cmykToRGBA(img image.Image) image.Image {
+	cmyk, isCMYK := img.(*image.CMYK)
+	if !isCMYK {
+		return img
+	}
+	rgba := image.NewRGBA(cmyk.Rect)
+	for y := cmyk.Rect.Min.Y; y < cmyk.Rect.Max.Y; y++ {
+		for x := cmyk.Rect.Min.X; x < cmyk.Rect.Max.X; x++ {
+			c := cmyk.CMYKAt(x, y)
+			r, g, b := color.CMYKToRGB(c.C, c.M, c.Y, c.K)
+			rgba.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: 0xFF})
+		}
+	}
+	return rgba
+}
+
+// repairJPEG rebuilds JPEG data from its known segments and scans. Everything after the first EOI marker (FF D9) is
+// trimmed, unknown markers and stray bytes between the segments are dropped, and a missing EOI marker is added.
+func repairJPEG(data []byte) []byte {
+	if !bytes.HasPrefix(data, jpegMagic) {
+		return data
+	}
+
+	repaired := append(make([]byte, 0, len(data)), jpegMagic...)
+	pos := len(jpegMagic)
+	for pos+1 < len(data) {
+		if data[pos] != 0xFF {
+			// Stray byte between two segments.
+			pos++
+			continue
+		}
+
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker.
+			pos++
+
+		case marker == 0xD9:
+			// EOI, anything after it is garbage.
+			return append(repaired, 0xFF, 0xD9)
+
+		case isJPEGSegment(marker):
+			if pos+4 > len(data) {
+				return append(repaired, 0xFF, 0xD9)
+			}
+			end := pos + 2 + (int(data[pos+2])<<8 | int(data[pos+3]))
+			if end < pos+4 || end > len(data) {
+				return append(repaired, 0xFF, 0xD9)
+			}
+			repaired = append(repaired, data[pos:end]...)
+			pos = end
+
+			if marker == 0xDA {
+				// The entropy coded data of a scan runs up to the next marker, stuffed bytes (FF 00) and restart
+				// markers (FF D0 to FF D7) are part of it.
+				for pos < len(data) {
+					if data[pos] == 0xFF && pos+1 < len(data) && data[pos+1] != 0x00 && (data[pos+1] < 0xD0 || data[pos+1] > 0xD7) {
+						break
+					}
+					if data[pos] == 0xFF && pos+1 < len(data) {
+						repaired = append(repaired, data[pos], data[pos+1])
+						pos += 2
+						continue
+					}
+					repaired = append(repaired, data[pos])
+					pos++
+				}
+			}
+
+		default:
+			// Unknown or misplaced marker without a length.
+			pos += 2
+		}
+	}
+
+	return append(repaired, 0xFF, 0xD9)
+}
+
+// isJPEGSegment checks whether a marker starts a segment with a length: SOFn, DHT, DAC, SOS, DQT, DNL, DRI, APPn and
+// COM.
+func isJPEGSegment(marker byte) bool {
+	return (marker >= 0xC0 && marker <= 0xCF) || (marker >= 0xDA && marker <= 0xDF) || (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE
+}
+
+// decodeStdJPEG decodes a JPEG tile with the pure Go decoder of the standard library.
+func decodeStdJPEG(data []byte) (image.Image, error) {
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
+// decodeImage decodes a tile with the last registered decoder whose magic bytes match, JPEG tiles with the selected
+// JPEG decoder.
+//
+// Images without any pixels are treated as undecodable.
+func decodeImage(data []byte, jpegDecoder string) (image.Image, error) {
+	for n := len(decoders) - 1; n >= 0; n-- {
+		if !bytes.HasPrefix(data, decoders[n].magic) {
+			continue
+		}
+
+		img, err := decoders[n].decode(data, jpegDecoder)
+		if err != nil {
+			return nil, err
+		}
+		if bounds := img.Bounds(); bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+			return nil, fmt.Errorf("image has no pixels (%vx%v)", bounds.Dx(), bounds.Dy())
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("unknown image format")
+}
+
+// tileExtension returns the file extension for the embedded data of a tile, bin for an unknown format.
+func tileExtension(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, jpegMagic):
+		return "jpg"
+	case bytes.HasPrefix(data, []byte{0x89, 0x50, 0x4E, 0x47}):
+		return "png"
+	}
+	return "bin"
+}
@@ -0,0 +1,167 @@
+package playview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"slices"
+	"testing"
+)
+
+// testJPEG encodes a tile sized gradient, like the tiles of a page.
+func testJPEG(tb testing.TB) []byte {
+	tb.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, tilePitch, tilePitch))
+	for y := 0; y < tilePitch; y++ {
+		for x := 0; x < tilePitch; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 0xFF})
+		}
+	}
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	if err != nil {
+		tb.Fatalf("unable to encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// jpegDecoderNames returns the available JPEG decoders, "turbo" only with -tags turbojpeg.
+func jpegDecoderNames() []string {
+	var names []string
+	for name := range jpegDecoders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestDecodeJPEG(t *testing.T) {
+	data := testJPEG(t)
+	for _, name := range jpegDecoderNames() {
+		img, err := decodeImage(data, name)
+		if err != nil {
+			t.Fatalf("%v: unable to decode: %v", name, err)
+		}
+		if bounds := img.Bounds(); bounds.Dx() != tilePitch || bounds.Dy() != tilePitch {
+			t.Errorf("%v: decoded %vx%v, expected %vx%v", name, bounds.Dx(), bounds.Dy(), tilePitch, tilePitch)
+		}
+	}
+}
+
+func BenchmarkDecodeJPEG(b *testing.B) {
+	data := testJPEG(b)
+	for _, name := range jpegDecoderNames() {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for n := 0; n < b.N; n++ {
+				_, err := decodeImage(data, name)
+				if err != nil {
+					b.Fatalf("unable to decode: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterDecoderOverridesJPEG(t *testing.T) {
+	registered := decoders
+	defer func() { decoders = registered }()
+
+	want := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	RegisterDecoder(jpegMagic, func(data []byte) (image.Image, error) {
+		return want, nil
+	})
+
+	img, err := decodeImage(testJPEG(t), "std")
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	if img != want {
+		t.Errorf("decoded with the built-in decoder, expected the registered one")
+	}
+}
+
+func TestDecodeUnknownFormat(t *testing.T) {
+	_, err := decodeImage([]byte("GIF89a"), "std")
+	if err == nil {
+		t.Errorf("decoded an unknown format")
+	}
+}
+
+func TestDecodeJPEGTrailingGarbage(t *testing.T) {
+	data := testJPEG(t)
+	garbage := append(append([]byte{}, data...), 0x12, 0xFF, 0x12, 0xFF, 0xD8, 0x00, 0xFF)
+
+	img, err := decodeImage(garbage, "std")
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != tilePitch || bounds.Dy() != tilePitch {
+		t.Errorf("decoded %vx%v, expected %vx%v", bounds.Dx(), bounds.Dy(), tilePitch, tilePitch)
+	}
+	if repaired := repairJPEG(garbage); !bytes.Equal(repaired, data) {
+		t.Errorf("repaired to %v bytes, expected the %v bytes up to the first EOI", len(repaired), len(data))
+	}
+}
+
+func TestDecodeJPEGUnknownMarker(t *testing.T) {
+	data := testJPEG(t)
+	// An unknown marker between the scan and the EOI marker.
+	broken := append(append([]byte{}, data[:len(data)-2]...), 0xFF, 0x12, 0xFF, 0xD9)
+
+	_, err := decodeStdJPEG(broken)
+	if err == nil {
+		t.Fatalf("decoded the broken tile without repairing it, the test needs another defect")
+	}
+	_, err = decodeImage(broken, "std")
+	if err != nil {
+		t.Errorf("unable to decode the repaired tile: %v", err)
+	}
+}
+
+func TestRepairJPEGKeepsValidData(t *testing.T) {
+	data := testJPEG(t)
+	if repaired := repairJPEG(data); !bytes.Equal(repaired, data) {
+		t.Errorf("changed valid data from %v to %v bytes", len(data), len(repaired))
+	}
+}
+
+func TestTileExtension(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{data: testJPEG(t), want: "jpg"},
+		{data: []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, want: "png"},
+		{data: []byte("GVMP"), want: "bin"},
+		{data: nil, want: "bin"},
+	}
+	for _, test := range tests {
+		if got := tileExtension(test.data); got != test.want {
+			t.Errorf("tileExtension(% X) = %v, expected %v", test.data[:min(4, len(test.data))], got, test.want)
+		}
+	}
+}
+
+func TestCMYKToRGBA(t *testing.T) {
+	cmyk := image.NewCMYK(image.Rect(2, 3, 5, 4))
+	cmyk.SetCMYK(2, 3, color.CMYK{C: 0xFF})
+	cmyk.SetCMYK(3, 3, color.CMYK{M: 0x80, Y: 0x80, K: 0x40})
+	cmyk.SetCMYK(4, 3, color.CMYK{K: 0xFF})
+
+	converted, isRGBA := cmykToRGBA(cmyk).(*image.RGBA)
+	if !isRGBA || converted.Rect != cmyk.Rect {
+		t.Fatalf("got %T with bounds %v, expected *image.RGBA with bounds %v", cmykToRGBA(cmyk), cmykToRGBA(cmyk).Bounds(), cmyk.Rect)
+	}
+	for x, want := range []color.RGBA{{0x00, 0xFF, 0xFF, 0xFF}, {0xBF, 0x5F, 0x5F, 0xFF}, {0x00, 0x00, 0x00, 0xFF}} {
+		if got := converted.RGBAAt(2+x, 3); got != want {
+			t.Errorf("pixel %v is %v, expected %v", 2+x, got, want)
+		}
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	if cmykToRGBA(rgba) != image.Image(rgba) {
+		t.Errorf("rgba image was converted")
+	}
+}
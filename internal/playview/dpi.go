@@ -0,0 +1,56 @@
+package playview
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Length of the png signature and the IHDR chunk, which image/png always writes first.
+const pngHeaderLength = 8 + 4 + 4 + 13 + 4
+
+// pngDPIWriter inserts a pHYs chunk with the resolution after the IHDR chunk of a png written by image/png (used by
+// -dpi).
+type pngDPIWriter struct {
+	w       io.Writer
+	dpi     int
+	written int
+}
+
+func (p *pngDPIWriter) Write(data []byte) (int, error) {
+	if p.written >= pngHeaderLength || p.written+len(data) < pngHeaderLength {
+		n, err := p.w.Write(data)
+		p.written += n
+		return n, err
+	}
+
+	// The header ends within data.
+	split := pngHeaderLength - p.written
+	n, err := p.w.Write(data[:split])
+	p.written += n
+	if err != nil {
+		return n, err
+	}
+	_, err = p.w.Write(physChunk(p.dpi))
+	if err != nil {
+		return n, err
+	}
+	rest, err := p.w.Write(data[split:])
+	p.written += rest
+	return n + rest, err
+}
+
+// physChunk returns a png pHYs chunk for the resolution, which is given in pixels per meter.
+func physChunk(dpi int) []byte {
+	pixelsPerMeter := uint32(math.Round(float64(dpi) / 0.0254))
+
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], pixelsPerMeter)
+	binary.BigEndian.PutUint32(chunk[12:], pixelsPerMeter)
+	chunk[16] = 1 // Unit is the meter.
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+	return chunk
+}
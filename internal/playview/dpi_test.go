@@ -0,0 +1,50 @@
+package playview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestPNGDPIWriter(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 9))
+	img.Pix[0] = 0xFF
+
+	var buf bytes.Buffer
+	err := png.Encode(&pngDPIWriter{w: &buf, dpi: 300}, img)
+	if err != nil {
+		t.Fatalf("unable to encode: %v", err)
+	}
+	data := buf.Bytes()
+
+	// The chunks follow the signature.
+	var chunks []string
+	var physData []byte
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		chunks = append(chunks, chunkType)
+		if chunkType == "pHYs" {
+			physData = data[pos+8 : pos+8+length]
+		}
+		pos += 12 + length
+	}
+	if len(chunks) < 3 || chunks[0] != "IHDR" || chunks[1] != "pHYs" {
+		t.Fatalf("chunks %v, expected pHYs right after IHDR", chunks)
+	}
+
+	// 300 dpi are 11811 pixels per meter.
+	if x, y := binary.BigEndian.Uint32(physData), binary.BigEndian.Uint32(physData[4:]); x != 11811 || y != 11811 || physData[8] != 1 {
+		t.Errorf("pHYs %v x %v unit %v, expected 11811 x 11811 per meter", x, y, physData[8])
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("decoded %v, expected %v", decoded.Bounds(), img.Bounds())
+	}
+}
@@ -0,0 +1,1174 @@
+package playview
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ExtractFile exports all requested pages of a single gvd.dat.
+func (e *Extractor) ExtractFile(filePath string) error {
+	if e.SourceHash {
+		err := e.hashSource(filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.ScanMode {
+		return e.scanFile(filePath)
+	}
+
+	e.logf("Reading %v", filePath)
+
+	if e.Resume && !e.progressLoaded {
+		err := e.loadProgress()
+		if err != nil {
+			return err
+		}
+		e.progressLoaded = true
+	}
+
+	f, err := e.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = f.ExportAll()
+	if err != nil {
+		return fmt.Errorf("unable to read databases: %v", err)
+	}
+
+	// Further containers may follow the first one (used by -multi-container).
+	for container := f; e.MultiContainer; {
+		next, found, err := container.nextContainer()
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+
+		err = next.ExportAll()
+		if err != nil {
+			return fmt.Errorf("unable to read databases of container %v: %v", next.container, err)
+		}
+		container = next
+	}
+
+	// A page left without a pair ends the book on its own (used by -spread).
+	return e.flushSpread()
+}
+
+// ExportAll exports all requested pages of the file.
+func (f *File) ExportAll() error {
+
+	f.checkImageTypes()
+
+	for _, i := range f.pageOrder() {
+
+		// Only export the requested pages.
+		if !f.IsTargetPage(f.Pages[i].FileName) {
+			continue
+		}
+
+		// Only export pages with dual images (used by -dual-only).
+		if f.DualOnly && f.peekImageType(i) != "gvmp" {
+			continue
+		}
+
+		f.logf("  > Handle [%v]", f.Pages[i].FileName)
+
+		// Number the output across all input files, a page that exports nothing leaves its number to the next one.
+		f.Pages[i].OutputName = f.Pages[i].FileName
+		if f.NumberPages {
+			f.Pages[i].OutputName = fmt.Sprintf("%04d_%v", f.pageNumber+1, f.Pages[i].FileName)
+		}
+		if f.container > 0 {
+			f.Pages[i].OutputName = path.Join(fmt.Sprintf("container_%v", f.container), f.Pages[i].OutputName)
+		}
+
+		// Pages are known by their output name before it is expanded by the template.
+		progressKey := f.Pages[i].OutputName
+		if f.Resume && f.completedPages[progressKey] {
+			f.logf("   .. Completed earlier")
+			f.pageNumber++
+			continue
+		}
+
+		if f.OnProgress != nil {
+			f.OnProgress(i, f.totalDataEntries, f.Pages[i].FileName)
+		}
+
+		crashed := len(f.Stats.CrashedPages)
+		exported := f.Stats.ExportedPages
+		err := f.exportPageRecovered(i)
+		if err != nil {
+			return fmt.Errorf("unable to export page %v [%v]: %v", i, f.Pages[i].FileName, err)
+		}
+		if f.Stats.ExportedPages > exported {
+			f.pageNumber++
+		}
+
+		if len(f.Stats.CrashedPages) == crashed {
+			err = f.markCompleted(progressKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	f.logf(" >> Databases done.")
+
+	return nil
+}
+
+// exportPageRecovered exports page i like ExportPage, but a panic while parsing the page is logged and counted
+// instead of ending the whole extraction.
+func (f *File) exportPageRecovered(i int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.Stats.FailedPages++
+			f.Stats.CrashedPages = append(f.Stats.CrashedPages, f.Pages[i].FileName)
+			log.Printf("  [ERROR] Page %v [%v] crashed: %v", i, f.Pages[i].FileName, r)
+		}
+	}()
+
+	return f.ExportPage(i)
+}
+
+// A page type shared by less than 1/imageTypeOutlierShare of the pages is an outlier.
+const imageTypeOutlierShare = 20
+
+// checkImageTypes warns about the pages whose image type is an outlier among all pages of the file. A lone gvmp page
+// among hundreds of jpeg pages is often a misparsed database.
+func (f *File) checkImageTypes() {
+	types := make([]string, f.totalDataEntries)
+	for i := range types {
+		types[i] = f.peekImageType(i)
+	}
+
+	for _, i := range imageTypeOutliers(types) {
+		imageType := types[i]
+		if imageType == "" {
+			imageType = "unknown"
+		}
+		f.warnf("Page %v [%v] is of type [%v] unlike most of the %v pages, its output may be wrong.", i, f.Pages[i].FileName, imageType, len(types))
+	}
+}
+
+// imageTypeOutliers returns the indexes of the pages whose image type is used by less than 1/imageTypeOutlierShare
+// of all pages.
+func imageTypeOutliers(types []string) []int {
+	counts := map[string]int{}
+	for _, imageType := range types {
+		counts[imageType]++
+	}
+
+	var outliers []int
+	for i, imageType := range types {
+		if counts[imageType]*imageTypeOutlierShare < len(types) {
+			outliers = append(outliers, i)
+		}
+	}
+	return outliers
+}
+
+// pageOrder returns the indexes of all pages in the order they are exported, by page table or by name (used by -order).
+func (f *File) pageOrder() []int {
+	order := make([]int, f.totalDataEntries)
+	for i := range order {
+		order[i] = i
+	}
+	if f.Order == "name" {
+		slices.SortStableFunc(order, func(a int, b int) int {
+			return naturalCompare(f.Pages[a].FileName, f.Pages[b].FileName)
+		})
+	}
+	return order
+}
+
+// tileOrder returns the indexes of the tiles of page i in the order they are merged. If the tiles are sorted by layer,
+// the offsets of the tiles within the embedded images are returned as well, otherwise nil.
+func (f *File) tileOrder(i int) ([]int, []int64) {
+	images := f.Pages[i].Images
+	order := make([]int, len(images))
+	for j := range order {
+		order[j] = j
+	}
+	if f.LayerOrder != "asc" && f.LayerOrder != "desc" {
+		return order, nil
+	}
+
+	offsets := tileOffsets(images)
+
+	// Later tiles are drawn on top, so with desc layer 0 (the highest resolution) wins.
+	slices.SortStableFunc(order, func(a int, b int) int {
+		if f.LayerOrder == "desc" {
+			return images[b].Layer - images[a].Layer
+		}
+		return images[a].Layer - images[b].Layer
+	})
+	return order, offsets
+}
+
+// tileOffsets returns the offset of each tile within the embedded images, the tiles follow each other with their
+// padding.
+func tileOffsets(images []ImageInfo) []int64 {
+	offsets := make([]int64, len(images))
+	for j := 1; j < len(images); j++ {
+		offsets[j] = offsets[j-1] + int64(images[j-1].FileLength) + int64(images[j-1].FileLengthPadding)
+	}
+	return offsets
+}
+
+// naturalCompare compares two names with embedded numbers by value, so "p2" comes before "p10".
+func naturalCompare(a string, b string) int {
+	for a != "" && b != "" {
+		digitsA, digitsB := leadingDigits(a), leadingDigits(b)
+		if digitsA > 0 && digitsB > 0 {
+			numberA := strings.TrimLeft(a[:digitsA], "0")
+			numberB := strings.TrimLeft(b[:digitsB], "0")
+			if len(numberA) != len(numberB) {
+				return len(numberA) - len(numberB)
+			}
+			if c := strings.Compare(numberA, numberB); c != 0 {
+				return c
+			}
+			a, b = a[digitsA:], b[digitsB:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// leadingDigits returns the number of decimal digits at the start of s.
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// ExportPage reads the database of page i and exports its images.
+func (f *File) ExportPage(i int) error {
+	if f.DumpDatabaseViewer && !f.rendering {
+		// Before parsing, so the database of a page the parser fails on is dumped as well.
+		err := f.dumpDatabaseViewer(i)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.readImageTable(i)
+
+	if f.Pages[i].OutputName == "" {
+		f.Pages[i].OutputName = f.Pages[i].FileName
+	}
+	if f.OutTemplate != "" {
+		f.Pages[i].OutputName = f.expandTemplate(i)
+	}
+
+	if f.Pages[i].entryTable != nil {
+		// [Keep the image table of an unsupported layout for analysis]
+		if f.rendering {
+			return nil
+		}
+		f.Stats.FailedPages++
+		if f.ValidateOnly {
+			return nil
+		}
+		name := fmt.Sprintf("%v_entries.bin", f.Pages[i].OutputName)
+		f.logf("   .. Image table written to [%v], skipped", name)
+		return f.writeBlock(path.Join(f.OutDir, name), f.Pages[i].entryTable)
+	}
+
+	// While rendering, the page is always merged and nothing but the merged image is produced (used by RenderPage).
+	merge := f.MergeImages || f.rendering
+	exportTiles := (!merge || f.ExportTiles) && !f.rendering && !f.TMSLayout
+	zoom := f.ZoomAnimation && !f.rendering
+	sideOutputs := !f.ValidateOnly && !f.rendering
+
+	if f.DumpBlocks && !f.rendering {
+		err := f.dumpBlocks(i)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.currentPage = i
+	defer func() {
+		f.currentPage = -1
+		f.currentTile = -1
+	}()
+	numImages := len(f.Pages[i].Images)
+
+	f.logf("   .. Type [%v]", f.Pages[i].ImageType)
+
+	// Layer to export, -1 for all.
+	pageLayer := f.TargetLayer
+	if f.ThumbsOnly {
+		layer, found := ThumbnailLayer(f.Pages[i])
+		if !found {
+			f.logf("   .. No thumbnail")
+			return nil
+		}
+		pageLayer = layer
+	}
+	if pageLayer != -1 && !f.FillFromLayer && !slices.Contains(f.Pages[i].Layers(), pageLayer) {
+		f.logf("   .. No tiles in layer [%v], skipped", pageLayer)
+		return nil
+	}
+
+	// Read BLK
+	f.readCompare([]byte{0x42, 0x4C, 0x4B, 0x5F})
+
+	// XXXX 	4 	xx xx xx xx 	Total length embedded images (with FF padding)
+	f.Pages[i].LengthImages, _ = f.readOffsetN(4)
+
+	if f.LogLevel >= LogVerbose {
+		log.Printf("[%v] lengthImages: %v", i, f.Pages[i].LengthImages)
+	}
+
+	// START IMAGES
+	f.readSectionCode("image")
+
+	// End of the embedded images, no tile may be read beyond it.
+	imagesStart, _ := f.handle.Seek(0, 1)
+	imagesEnd := imagesStart + f.Pages[i].LengthImages
+
+	canvasWidth, canvasHeight := f.pageCanvasSize(i, pageLayer)
+
+	// Create a new image, or one per layer (used by -layers-separate).
+	mergedImages := map[int]*image.RGBA{}
+	mergedImageFor := func(layer int) *image.RGBA {
+		if !f.SeparateLayers {
+			layer = 0
+		}
+		if _, exists := mergedImages[layer]; !exists {
+			mergedImages[layer] = newCanvas(canvasWidth, canvasHeight)
+		}
+		return mergedImages[layer]
+	}
+	// A page of a single full size tile is saved as it is decoded, without a canvas.
+	direct := merge && f.isSingleTilePage(i, pageLayer, canvasWidth, canvasHeight) && !f.SeparateLayers && !zoom &&
+		!f.AlignLayers && !f.DebugGrid && !f.FillFromLayer && !f.WhiteToAlpha && f.Clip.Empty() && f.GridOffsetX == 0 && f.GridOffsetY == 0
+	var directImage image.Image
+
+	if !f.SeparateLayers && !direct {
+		mergedImageFor(0)
+	}
+
+	// Detect overlaps, the map is reused by all pages.
+	if f.handled == nil {
+		f.handled = map[string]bool{}
+	}
+	handled := f.handled
+	clear(handled)
+	overlaps := 0
+
+	// Track if any data has been added.
+	hasAnyImageData := false
+
+	// Track if any tile could not be decoded.
+	pageFailed := false
+
+	var rawImage []byte
+
+	// Grid stride, may be replaced by the size of the first tile (used by -auto-pitch).
+	pitchW, pitchH := tilePitch, tilePitch
+	pitchDetected := false
+
+	// Count the tiles per grid position in advance (used by -overlap skip).
+	tileCounts := map[string]int{}
+	for _, img := range f.Pages[i].Images {
+		if pageLayer == -1 || img.Layer == pageLayer {
+			tileCounts[f.gridKey(img)]++
+		}
+	}
+
+	// Tiles exported for this page (used by -dedup).
+	var tileMap []TileMapping
+
+	// Destinations of the merged tiles (used by -debug-grid).
+	var placements []tilePlacement
+
+	// Decoded tiles of the page, only collected when they are put together afterwards (used by -tile-montage and
+	// -sprite-sheet).
+	var montageTiles []montageTile
+	collectTiles := (f.TileMontage || f.SpriteSheet) && sideOutputs
+	collectTile := func(j int, img image.Image) {
+		if collectTiles {
+			montageTiles = append(montageTiles, montageTile{index: j, info: f.Pages[i].Images[j], img: img})
+		}
+	}
+
+	// Only the clipped merged image can do without the tiles outside of the clip region. The clip is given in the
+	// coordinates of layer 0, so tiles of upscaled layers are always needed.
+	needAllTiles := !merge || f.Clip.Empty() || exportTiles || zoom || collectTiles || f.AlignLayers ||
+		(f.TMSLayout && sideOutputs) || f.OnImage != nil
+
+	// Tiles are read in file order unless they are sorted by layer (used by -layer-order).
+	tileOrder, tileOffsets := f.tileOrder(i)
+
+	for _, j := range tileOrder {
+		f.currentTile = j
+		if tileOffsets != nil {
+			_, _ = f.handle.Seek(imagesStart+tileOffsets[j], 0)
+		}
+
+		// Skip if not the targeted layer.
+		layer := f.Pages[i].Images[j].Layer
+		if pageLayer != -1 && layer != pageLayer {
+			_, _ = f.handle.Seek(int64(f.Pages[i].Images[j].FileLength)+int64(f.Pages[i].Images[j].FileLengthPadding), 1)
+			continue
+		}
+
+		posW := f.Pages[i].Images[j].GridPosW
+		posH := f.Pages[i].Images[j].GridPosH
+
+		// Skip if not selected by the tile list.
+		if !f.TileList.Allows(posW, posH) {
+			_, _ = f.handle.Seek(int64(f.Pages[i].Images[j].FileLength)+int64(f.Pages[i].Images[j].FileLengthPadding), 1)
+			continue
+		}
+
+		// Skip if outside of the clip region and no other output needs the tile (used by -clip). Until the pitch is
+		// detected, the position of a tile is not known.
+		if !needAllTiles && (!f.AutoPitch || pitchDetected) {
+			x := posW*pitchW + f.GridOffsetX
+			y := posH*pitchH + f.GridOffsetY
+			tile := image.Rect(x, y, x+f.Pages[i].Images[j].Width, y+f.Pages[i].Images[j].Height)
+			if !tile.Overlaps(f.Clip) {
+				_, _ = f.handle.Seek(int64(f.Pages[i].Images[j].FileLength)+int64(f.Pages[i].Images[j].FileLengthPadding), 1)
+				continue
+			}
+		}
+
+		if f.LogLevel >= LogTrace {
+			log.Printf("")
+			log.Printf("Image %v at %v;%v", j, posW, posH)
+		}
+
+		// Which of the two images of a dual image is read, A (the first) or B (the second), empty for regular images.
+		dualImage := ""
+
+		if f.Pages[i].ImageType == "gvmp" {
+			// [Dual Image]
+
+			tileStart, _ := f.handle.Seek(0, 1)
+			if f.LogLevel >= LogTrace {
+				log.Printf(" POS-BEFORE %v", tileStart)
+			}
+
+			f.readCompare([]byte{0x47, 0x56, 0x4D, 0x50}) // Header "GVMP".
+			f.readCompare([]byte{0x00, 0x00, 0x00, 0x02}) // Unused ? Maybe number of images? 2
+			f.readCompare([]byte{0x00, 0x00, 0x00, 0x20}) // Unused ? Maybe header length? 32
+			imageLength, _ := f.readUint32()              // file length
+			paddedImageLength, _ := f.readUint32()        // Only if paddedImageLength != 32
+			secondImageLength, _ := f.readUint32()        // Only if paddedImageLength != 32
+			f.readCompare([]byte{0x00, 0x00, 0x00, 0x00}) // Unused ? Maybe padding? 0
+			f.readCompare([]byte{0x00, 0x00, 0x00, 0x00}) // Unused ? Maybe padding? 0
+
+			if f.LogLevel >= LogTrace {
+				log.Printf("(A) %v; %v; %v", imageLength, paddedImageLength, secondImageLength)
+			}
+
+			// Both images have to lie within the embedded images of the page.
+			tileLength := int64(imageLength)
+			if paddedImageLength != 32 {
+				tileLength = int64(paddedImageLength-32) + int64(secondImageLength)
+			}
+			pos, _ := f.handle.Seek(0, 1)
+			if (paddedImageLength != 32 && paddedImageLength < imageLength+32) || pos+tileLength > imagesEnd {
+				f.Stats.FailedTiles++
+				pageFailed = true
+				f.warnf("Image %v at %v, %v with lengths %v; %v; %v exceeds the page data at %v, skipped.", j, posW, posH, imageLength, paddedImageLength, secondImageLength, f.location())
+				collectTile(j, nil)
+
+				// Continue with the next tile as given by the image table.
+				next := tileStart + int64(f.Pages[i].Images[j].FileLength) + int64(f.Pages[i].Images[j].FileLengthPadding)
+				_, _ = f.handle.Seek(min(next, imagesEnd), 0)
+				continue
+			}
+
+			// Skip first image by jumping the original file length.
+			dualImage = "A"
+			if f.LoadFullImages && paddedImageLength != 32 {
+				_, _ = f.handle.Seek(int64(paddedImageLength-32), 1)
+				imageLength = secondImageLength
+				dualImage = "B"
+			}
+
+			if f.MaxTileBytes > 0 && imageLength > f.MaxTileBytes {
+				f.skipLargeTile(i, j, imageLength)
+				collectTile(j, nil)
+				pageFailed = true
+				next := tileStart + int64(f.Pages[i].Images[j].FileLength) + int64(f.Pages[i].Images[j].FileLengthPadding)
+				_, _ = f.handle.Seek(min(next, imagesEnd), 0)
+				continue
+			}
+			rawImage, _ = f.readScratch(imageLength)
+
+			if !f.LoadFullImages && paddedImageLength != 32 {
+				// Move by the first padding.
+				_, _ = f.handle.Seek(int64(paddedImageLength-imageLength-32), 1)
+				// Move by the second image.
+				_, _ = f.handle.Seek(int64(secondImageLength), 1)
+			}
+
+			// Align to next 16 byte block.
+			pos, _ = f.handle.Seek(0, 1)
+			paddingOffset := (pos - f.base) % 16
+			if paddingOffset != 0 {
+				_, _ = f.handle.Seek(16-paddingOffset, 1)
+			}
+
+		} else {
+			// [Regular Image]
+
+			// The image has to lie within the embedded images of the page.
+			pos, _ := f.handle.Seek(0, 1)
+			if pos+int64(f.Pages[i].Images[j].FileLength) > imagesEnd {
+				f.Stats.FailedTiles++
+				pageFailed = true
+				f.warnf("Image %v at %v, %v with length %v exceeds the page data at %v, skipped.", j, posW, posH, f.Pages[i].Images[j].FileLength, f.location())
+				collectTile(j, nil)
+				_, _ = f.handle.Seek(imagesEnd, 0)
+				continue
+			}
+
+			if f.MaxTileBytes > 0 && f.Pages[i].Images[j].FileLength > f.MaxTileBytes {
+				f.skipLargeTile(i, j, f.Pages[i].Images[j].FileLength)
+				collectTile(j, nil)
+				pageFailed = true
+				_, _ = f.handle.Seek(int64(f.Pages[i].Images[j].FileLength)+int64(f.Pages[i].Images[j].FileLengthPadding), 1)
+				continue
+			}
+
+			// Load the image.
+			rawImage, _ = f.readScratch(f.Pages[i].Images[j].FileLength)
+		}
+
+		singleImage, err := decodeImage(rawImage, f.JPEGDecoder)
+		if err != nil {
+			// [Not an image]
+
+			f.Stats.FailedTiles++
+			pageFailed = true
+			collectTile(j, nil)
+
+			if !sideOutputs || (merge && f.SkipMergeRaw) {
+				// Leave a transparent gap in the merged image.
+				f.warnf("Unable to decode image %v at %v, %v: %v", j, posW, posH, err)
+			} else {
+				// Export raw for analysis.
+				err := f.writeRaw(fmt.Sprintf("%v_%v", f.Pages[i].OutputName, j), rawImage)
+				if err != nil {
+					return err
+				}
+			}
+
+		} else {
+			// [Image]
+			hasAnyImageData = true
+			f.Stats.DecodedTiles++
+			collectTile(j, singleImage)
+
+			if f.OnImage != nil {
+				f.OnImage(f.Pages[i].FileName, j, singleImage)
+			}
+
+			// Check if a file is overlapping.
+			handleKey := f.gridKey(f.Pages[i].Images[j])
+			_, overlapping := handled[handleKey]
+			if overlapping {
+				overlaps++
+				f.warnf("Overlapping image at %v, %v detected.", f.Pages[i].Images[j].GridPosW, f.Pages[i].Images[j].GridPosH)
+			}
+			handled[handleKey] = true
+
+			// Decide which of the overlapping tiles ends up in the merged image, an empty mode is "last".
+			mergeTile := true
+			if f.OverlapMode == "first" && overlapping {
+				mergeTile = false
+			} else if f.OverlapMode == "skip" && tileCounts[handleKey] > 1 {
+				mergeTile = false
+			}
+
+			if f.AutoPitch && !pitchDetected {
+				pitchW = singleImage.Bounds().Dx()
+				pitchH = singleImage.Bounds().Dy()
+				pitchDetected = true
+				f.logf("   .. Pitch [%vx%v]", pitchW, pitchH)
+			}
+
+			if merge {
+				// [Build the merged image]
+				if mergeTile && direct && singleImage.Bounds().Size() == image.Pt(canvasWidth, canvasHeight) {
+					directImage = singleImage
+				} else if mergeTile {
+					x := posW*pitchW + f.GridOffsetX
+					y := posH*pitchH + f.GridOffsetY
+					// Tiles are placed 1:1, only whole merged images are ever resized (see -resample).
+					bounds := singleImage.Bounds()
+					draw.Draw(mergedImageFor(layer), image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), singleImage, bounds.Min, draw.Over)
+					placements = append(placements, tilePlacement{index: j, layer: layer, rect: image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy())})
+				}
+			}
+
+			if f.TMSLayout && sideOutputs {
+				// [Save the tile data unchanged by grid position]
+				err := f.writeTMSTile(f.Pages[i], f.Pages[i].Images[j], rawImage)
+				if err != nil {
+					return err
+				}
+			}
+
+			if exportTiles {
+				// [Save each image]
+				tileName := fmt.Sprintf("%v_%v_%v_%v", f.Pages[i].OutputName, j, posW, posH)
+				if f.ThumbsOnly {
+					tileName = fmt.Sprintf("%v_thumb", f.Pages[i].OutputName)
+				} else if f.DualTileNames && dualImage != "" {
+					tileName = fmt.Sprintf("%v_layer_%v_%v_%v_%v_%v", f.Pages[i].OutputName, layer, dualImage, j, posW, posH)
+				}
+
+				writeTile := true
+
+				if f.DedupTiles {
+					hash := sha256.Sum256(rawImage)
+					if sharedName, exists := f.writtenTiles[hash]; exists {
+						tileName = sharedName
+						writeTile = false
+					} else {
+						f.writtenTiles[hash] = tileName
+					}
+					tileMap = append(tileMap, TileMapping{Index: j, X: posW, Y: posH, Layer: layer, Name: tileName, Reserved: f.Pages[i].Images[j].Reserved})
+				}
+
+				if writeTile && f.RawTiles {
+					if sideOutputs {
+						err := f.writeTileData(tileName, rawImage)
+						if err != nil {
+							return err
+						}
+					}
+				} else if writeTile {
+					err := f.sinkImage(tileName, singleImage)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		// Skip padding, also after an undecodable tile, so the next tile is read from its start.
+		_, _ = f.handle.Seek(int64(f.Pages[i].Images[j].FileLengthPadding), 1)
+	}
+
+	if merge && f.FillFromLayer && pageLayer != -1 && !f.SeparateLayers {
+		// [Fill the cells without a tile from other layers]
+		filled := f.fillMissingCells(i, pageLayer, mergedImageFor(0), imagesStart, imagesEnd, pitchW, pitchH)
+		if filled > 0 {
+			hasAnyImageData = true
+			f.logf("   .. Filled [%v] cells from other layers", filled)
+		}
+	}
+
+	if merge && f.WhiteToAlpha {
+		// [Knock out white pixels]
+		for _, canvas := range mergedImages {
+			whiteToAlpha(canvas, f.WhiteThreshold)
+		}
+	}
+
+	if f.TileMontage && sideOutputs && len(montageTiles) > 0 {
+		err := f.writeTileMontages(f.Pages[i].OutputName, montageTiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.SpriteSheet && sideOutputs && len(montageTiles) > 0 {
+		// [Save the tiles packed into one image for game engines]
+		err := f.writeSpriteSheet(f.Pages[i].OutputName, montageTiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	if merge && hasAnyImageData {
+
+		// [Save the merged image]
+		var layers []int
+		for layer := range mergedImages {
+			layers = append(layers, layer)
+		}
+		slices.Sort(layers)
+		if directImage != nil && len(layers) == 0 {
+			layers = []int{0}
+		}
+
+		if zoom {
+			// [Save the layers as animation]
+			err := f.writeZoomAnimation(f.Pages[i], layers, mergedImages, pitchW, pitchH)
+			if err != nil {
+				return err
+			}
+			layers = nil
+		}
+
+		for _, layer := range layers {
+			imageName := f.Pages[i].OutputName
+			if f.SeparateLayers {
+				imageName = path.Join(fmt.Sprintf("layer_%v", layer), imageName)
+			}
+			canvas := mergedImages[layer]
+			if f.AlignLayers && layer != 0 {
+				// [Upscale the layer to the size of layer 0]
+				scaled, found := f.scaleLayer(f.Pages[i], layer, mergedImages[layer], pitchW, pitchH)
+				if !found {
+					continue
+				}
+				canvas = scaled
+			}
+			var finalImage image.Image = canvas
+			if directImage != nil && canvas == nil {
+				finalImage = directImage
+				if _, isRGBA := directImage.(*image.RGBA); !isRGBA {
+					// Decoded jpeg tiles are kept in their own color model, which png saves with 16 bits per channel.
+					finalImage = toRGBA(directImage)
+				}
+			}
+			if !f.Clip.Empty() {
+				clip := f.Clip.Intersect(canvas.Bounds())
+				if clip.Empty() {
+					f.warnf("Clip region %v is outside of the page.", f.Clip)
+					continue
+				}
+				finalImage = canvas.SubImage(clip)
+			}
+			finalImage = flipImage(rotateImage(finalImage, f.RotateDegrees), f.FlipDirection)
+			if bounds := finalImage.Bounds(); f.PreviewWidth > 0 && bounds.Dx() > f.PreviewWidth {
+				finalImage = ResampleImage(finalImage, f.PreviewWidth, max(1, bounds.Dy()*f.PreviewWidth/bounds.Dx()), f.Resample)
+			}
+			if f.MaxMegapixels > 0 {
+				bounds := finalImage.Bounds()
+				if width, height, scaled := MegapixelSize(bounds.Dx(), bounds.Dy(), f.MaxMegapixels); scaled {
+					finalImage = ResampleImage(finalImage, width, height, f.Resample)
+					f.logf("   .. Scaled by %.3f to [%vx%v] for %v megapixels", float64(width)/float64(bounds.Dx()), width, height, f.MaxMegapixels)
+				}
+			}
+			if f.FitWidth > 0 {
+				finalImage = fitImage(finalImage, f.FitWidth, f.FitHeight, f.FitBackground, f.Resample)
+			}
+			if f.BitDepth == 16 {
+				finalImage = toRGBA64(finalImage)
+			}
+			if f.rendering {
+				// The lowest layer (the highest resolution) is the result.
+				f.rendered = finalImage
+				break
+			}
+			err := f.emitPage(imageName, finalImage)
+			if err != nil {
+				return err
+			}
+
+			if f.SplitChannels && sideOutputs {
+				// [Save the channels as grayscale images]
+				err := f.writeChannels(imageName, finalImage)
+				if err != nil {
+					return err
+				}
+			}
+
+			if f.DebugGrid && sideOutputs {
+				// [Save the tile outlines]
+				err := f.writeDebugGrid(imageName, mergedImages[layer], layer, placements)
+				if err != nil {
+					return err
+				}
+			}
+
+			if f.WriteSidecar && sideOutputs {
+				// [Save the page metadata next to the image]
+				err := f.writeJSON(imageName, PageSidecar{
+					Name:         f.Pages[i].FileName,
+					Width:        f.Pages[i].ImageWidth,
+					Height:       f.Pages[i].ImageHeight,
+					ImageType:    f.Pages[i].ImageType,
+					Tiles:        numImages,
+					Layers:       f.Pages[i].Layers(),
+					Overlaps:     overlaps,
+					Title:        f.Pages[i].Title,
+					SourceSHA256: f.sourceHash,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// The merged images can be reused unless a hook or a custom Sink may have kept them.
+	if f.builtinSink && f.OnPage == nil && !f.rendering {
+		for _, canvas := range mergedImages {
+			recycleCanvas(canvas)
+		}
+	}
+
+	if pageFailed {
+		f.Stats.FailedPages++
+	}
+
+	if hasAnyImageData {
+		f.Stats.ProducedPages[f.Pages[i].FileName] = true
+		if !f.rendering {
+			f.Stats.ExportedPages++
+		}
+	}
+
+	if f.TMSLayout && sideOutputs && hasAnyImageData {
+		// [Save the grid extents of the layers next to the tiles]
+		metadata := tmsMetadata(f.Pages[i], pageLayer, pitchW, pitchH)
+		err := f.writeJSON(path.Join(f.Pages[i].OutputName, "metadata"), metadata)
+		if err != nil {
+			return err
+		}
+
+		if f.HTMLViewer {
+			// [Save a viewer assembling the tiles in the browser]
+			err := f.writeViewer(f.Pages[i], metadata)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(tileMap) > 0 && sideOutputs {
+		// [Save the mapping of grid positions to the shared tiles]
+		err := f.writeJSON(fmt.Sprintf("%v_tiles", f.Pages[i].OutputName), tileMap)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.logf("   .. Exported")
+
+	return nil
+}
+
+// ErrNoImage is returned by RenderPage for a page that did not produce an image.
+var ErrNoImage = errors.New("page has no image data")
+
+// RenderPage merges the page with the given name and returns the image instead of passing it to the Sink.
+//
+// Nothing is written and no hooks but OnImage are called. The options that shape the merged image (layers, clip,
+// rotation, fit, ...) apply as for ExportPage. If the page is merged into several images (e.g. with SeparateLayers),
+// the one of the lowest layer is returned.
+func (f *File) RenderPage(name string) (image.Image, error) {
+	i := f.FindPage(name)
+	if i == -1 {
+		return nil, fmt.Errorf("unknown page %v", name)
+	}
+
+	f.rendering, f.rendered = true, nil
+	defer func() {
+		f.rendering, f.rendered = false, nil
+	}()
+
+	err := f.ExportPage(i)
+	if err != nil {
+		return nil, err
+	}
+	if f.rendered == nil {
+		return nil, ErrNoImage
+	}
+	return f.rendered, nil
+}
+
+// PNGSink is the default sink and stores each image as <dir>/<pageName>.png, with the given resolution in dots per
+// inch unless dpi is 0.
+//
+// The image is encoded into a temporary file first, so an interrupted run never leaves a truncated page behind.
+func PNGSink(dir string, dpi int) ImageSink {
+	return WriterSink(FileOutput{Dir: dir}, dpi)
+}
+
+// writePNG encodes an image to filePath through a temporary file, with a pHYs chunk for the dpi unless it is 0.
+func writePNG(filePath string, img image.Image, dpi int) error {
+	w, err := createPartFile(filePath)
+	if err != nil {
+		return err
+	}
+	return encodePNG(w, img, dpi)
+}
+
+// encodePNG encodes an image into an OutputWriter and completes it, with a pHYs chunk for the dpi unless it is 0.
+func encodePNG(w OutputWriter, img image.Image, dpi int) error {
+	var out io.Writer = w
+	if dpi > 0 {
+		out = &pngDPIWriter{w: w, dpi: dpi}
+	}
+	err := png.Encode(out, img)
+	if err != nil {
+		w.Abort()
+		return fmt.Errorf("unable to encode png: %v", err)
+	}
+	return w.Close()
+}
+
+// createParentDir creates the folder (and its parents) an output file is written to.
+func createParentDir(filePath string) error {
+	err := os.MkdirAll(path.Dir(filePath), 0755)
+	if err != nil {
+		return fmt.Errorf("unable to create output folder: %v", err)
+	}
+	return nil
+}
+
+// skipLargeTile counts tile j of page i as failed because its length is above MaxTileBytes.
+func (f *File) skipLargeTile(i int, j int, length int) {
+	f.Stats.FailedTiles++
+	img := f.Pages[i].Images[j]
+	f.warnf("Image %v at %v, %v with length %v is larger than %v bytes at %v, skipped.", j, img.GridPosW, img.GridPosH, length, f.MaxTileBytes, f.location())
+}
+
+// warnf logs a warning and counts it.
+func (e *Extractor) warnf(format string, v ...any) {
+	e.Stats.Warnings++
+	log.Printf("  [WARNING] "+format, v...)
+}
+
+// logf logs the progress, unless LogLevel is LogQuiet.
+func (e *Extractor) logf(format string, v ...any) {
+	if e.LogLevel > LogQuiet {
+		log.Printf(format, v...)
+	}
+}
+
+// acquireWrite waits for a free write slot and returns the function to release it again.
+func (e *Extractor) acquireWrite() func() {
+	if e.WriteSlots == nil {
+		return func() {}
+	}
+	e.WriteSlots <- struct{}{}
+	return func() {
+		<-e.WriteSlots
+	}
+}
+
+// sinkImage passes an image to the Sink within a write slot.
+func (e *Extractor) sinkImage(pageName string, img image.Image) error {
+	defer e.acquireWrite()()
+	return e.Sink(pageName, img)
+}
+
+// writeSlotPNG encodes an image to filePath within a write slot.
+func (e *Extractor) writeSlotPNG(filePath string, img image.Image) error {
+	defer e.acquireWrite()()
+	return writePNG(filePath, img, 0)
+}
+
+// writeJSON stores a value as <OutDir>/<name>.json.
+func (e *Extractor) writeJSON(name string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode json: %v", err)
+	}
+	filePath := path.Join(e.OutDir, fmt.Sprintf("%v.json", name))
+	defer e.acquireWrite()()
+	err = createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filePath, raw, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write json: %v", err)
+	}
+	return nil
+}
+
+// writeTileData stores the embedded data of a tile unchanged as <OutDir>/<name>.jpg (or .png).
+func (e *Extractor) writeTileData(name string, data []byte) error {
+	filePath := path.Join(e.OutDir, fmt.Sprintf("%v.%v", name, tileExtension(data)))
+	defer e.acquireWrite()()
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write tile: %v", err)
+	}
+	return nil
+}
+
+// writeRaw stores undecodable data as <OutDir>/<name>.raw for analysis.
+func (e *Extractor) writeRaw(name string, data []byte) error {
+	filePath := path.Join(e.OutDir, fmt.Sprintf("%v.raw", name))
+	defer e.acquireWrite()()
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	rawFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %v", err)
+	}
+	_, writeErr := rawFile.Write(data)
+	if writeErr != nil {
+		return fmt.Errorf("unable to write raw data: %v", writeErr)
+	}
+	closeErr := rawFile.Close()
+	if closeErr != nil {
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+	return nil
+}
+
+// ThumbnailLayer finds the layer holding the embedded thumbnail of a page.
+//
+// The lowest resolution layer (the highest number) is a ready-made thumbnail if it consists of a single tile.
+func ThumbnailLayer(page PageInfo) (int, bool) {
+	layer := -1
+	for _, img := range page.Images {
+		layer = max(layer, img.Layer)
+	}
+	if layer <= 0 {
+		return -1, false
+	}
+
+	tiles := 0
+	for _, img := range page.Images {
+		if img.Layer == layer {
+			tiles++
+		}
+	}
+	return layer, tiles == 1
+}
+
+// isSingleTilePage checks whether the layer of page i consists of a single tile at the origin that covers the whole
+// canvas, pageLayer is -1 for all layers.
+func (f *File) isSingleTilePage(i int, pageLayer int, canvasWidth int, canvasHeight int) bool {
+	tiles := 0
+	for _, img := range f.Pages[i].Images {
+		if pageLayer != -1 && img.Layer != pageLayer {
+			continue
+		}
+		tiles++
+		if tiles > 1 || img.GridPosW != 0 || img.GridPosH != 0 || img.Width != canvasWidth || img.Height != canvasHeight {
+			return false
+		}
+	}
+	return tiles == 1
+}
+
+// Pages larger than this factor times the area covered by tiles are suspect.
+const maxPageCoverageFactor = 8
+
+// pageCanvasSize returns the size of the merged image of page i.
+//
+// If the page is far larger than the area covered by its tiles, the page size is likely misparsed. This is reported
+// and, with -autocrop, the canvas is reduced to the extent of the tiles.
+func (f *File) pageCanvasSize(i int, pageLayer int) (int, int) {
+	width, height := f.Pages[i].ImageWidth, f.Pages[i].ImageHeight
+
+	tileCount := 0
+	extentW, extentH := 0, 0
+	for _, img := range f.Pages[i].Images {
+		if pageLayer != -1 && img.Layer != pageLayer {
+			continue
+		}
+		tileCount++
+		extentW = max(extentW, img.GridPosW*tilePitch+img.Width)
+		extentH = max(extentH, img.GridPosH*tilePitch+img.Height)
+	}
+
+	coveredArea := tileCount * tilePitch * tilePitch
+	if tileCount == 0 || width*height <= maxPageCoverageFactor*coveredArea {
+		return width, height
+	}
+
+	f.warnf("Page size %vx%v is more than %v times the area covered by %v tiles.", width, height, maxPageCoverageFactor, tileCount)
+	if f.AutoCrop {
+		width, height = min(width, extentW), min(height, extentH)
+		f.logf("   .. Cropped to [%vx%v]", width, height)
+	}
+
+	return width, height
+}
+
+// writeZoomAnimation stores the merged layers of a page as <OutDir>/<page>_zoom.png, from the lowest to the highest
+// resolution. Each layer is cropped to the area covered by its tiles and scaled to the size of the page.
+func (f *File) writeZoomAnimation(page PageInfo, layers []int, mergedImages map[int]*image.RGBA, pitchW int, pitchH int) error {
+	var frames []image.Image
+	for n := len(layers) - 1; n >= 0; n-- {
+		frame, found := f.scaleLayer(page, layers[n], mergedImages[layers[n]], pitchW, pitchH)
+		if !found {
+			continue
+		}
+		frames = append(frames, flipImage(rotateImage(frame, f.RotateDegrees), f.FlipDirection))
+	}
+
+	if f.ValidateOnly || len(frames) == 0 {
+		return nil
+	}
+
+	filePath := path.Join(f.OutDir, fmt.Sprintf("%v_zoom.png", page.OutputName))
+	defer f.acquireWrite()()
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	animFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %v", err)
+	}
+	err = writeAPNG(animFile, frames, 1000)
+	if err != nil {
+		_ = animFile.Close()
+		return fmt.Errorf("unable to encode animation: %v", err)
+	}
+	closeErr := animFile.Close()
+	if closeErr != nil {
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+	return nil
+}
+
+// scaleLayer crops the merged image of a layer to the area covered by its tiles and scales it to the size of the page.
+func (f *File) scaleLayer(page PageInfo, layer int, canvas *image.RGBA, pitchW int, pitchH int) (*image.RGBA, bool) {
+	extent := f.layerExtent(page, layer, pitchW, pitchH).Intersect(canvas.Bounds())
+	if extent.Empty() {
+		return nil, false
+	}
+
+	return ResampleImage(canvas.SubImage(extent), canvas.Bounds().Dx(), canvas.Bounds().Dy(), f.Resample), true
+}
+
+// gridKey identifies the grid position of an image, per layer if layers are merged separately.
+func (f *File) gridKey(img ImageInfo) string {
+	if f.SeparateLayers {
+		return fmt.Sprintf("%v-%v-%v", img.Layer, img.GridPosW, img.GridPosH)
+	}
+	return fmt.Sprintf("%v-%v", img.GridPosW, img.GridPosH)
+}
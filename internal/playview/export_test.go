@@ -0,0 +1,332 @@
+package playview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "p2", b: "p10", want: -1},
+		{a: "p10", b: "p2", want: 1},
+		{a: "p010", b: "p10", want: 0},
+		{a: "p1", b: "p1", want: 0},
+		{a: "a", b: "b", want: -1},
+		{a: "p1", b: "p1a", want: -1},
+		{a: "c1p2", b: "c1p10", want: -1},
+		{a: "c2p1", b: "c10p1", want: -1},
+		{a: "99", b: "100", want: -1},
+		{a: "", b: "p1", want: -1},
+	}
+	for _, test := range tests {
+		got := naturalCompare(test.a, test.b)
+		if sign(got) != test.want {
+			t.Errorf("naturalCompare(%q, %q) = %v, expected a result with sign %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestNaturalOrder(t *testing.T) {
+	names := []string{"p10", "cover", "p2", "p1", "p001a", "p20"}
+	slices.SortStableFunc(names, naturalCompare)
+
+	want := []string{"cover", "p1", "p001a", "p2", "p10", "p20"}
+	if !slices.Equal(names, want) {
+		t.Errorf("sorted %v, expected %v", names, want)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestSinkImageReleasesSlotOnPanic(t *testing.T) {
+	e := New(Options{
+		WriteSlots: make(chan struct{}, 1),
+		Sink: func(pageName string, img image.Image) error {
+			panic("sink failed")
+		},
+	})
+
+	// A second write would block forever if the first one kept its slot.
+	for n := 0; n < 2; n++ {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			_ = e.sinkImage("p001", nil)
+		}()
+	}
+	if len(e.WriteSlots) != 0 {
+		t.Errorf("%v write slots still taken", len(e.WriteSlots))
+	}
+}
+
+func TestImageTypeOutliers(t *testing.T) {
+	many := func(imageType string, n int) []string {
+		types := make([]string, n)
+		for i := range types {
+			types[i] = imageType
+		}
+		return types
+	}
+
+	tests := []struct {
+		name  string
+		types []string
+		want  []int
+	}{
+		{name: "empty", types: nil, want: nil},
+		{name: "uniform", types: many("jpeg", 50), want: nil},
+		{name: "small mixed book", types: []string{"jpeg", "gvmp", "jpeg", "jpeg"}, want: nil},
+		{name: "lone gvmp", types: append(many("jpeg", 40), "gvmp"), want: []int{40}},
+		{name: "lone unknown", types: append([]string{""}, many("gvmp", 30)...), want: []int{0}},
+		{name: "common second type", types: append(many("jpeg", 40), many("gvmp", 10)...), want: nil},
+	}
+	for _, test := range tests {
+		got := imageTypeOutliers(test.types)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%v: imageTypeOutliers = %v, expected %v", test.name, got, test.want)
+		}
+	}
+}
+
+// writeTestBook writes a gvd.dat with the given number of jpeg pages of grid x grid tiles into a temporary folder and
+// returns its path.
+func writeTestBook(tb testing.TB, pages int, grid int) string {
+	tb.Helper()
+	u32 := func(b *bytes.Buffer, v int) {
+		_ = binary.Write(b, binary.BigEndian, uint32(v))
+	}
+
+	tile := testJPEG(tb)
+	padding := (16 - len(tile)%16) % 16
+
+	var table, images bytes.Buffer
+	for y := 0; y < grid; y++ {
+		for x := 0; x < grid; x++ {
+			for _, v := range []int{x, y, 0, len(tile), padding, 0, tilePitch, tilePitch} {
+				u32(&table, v)
+			}
+			images.Write(tile)
+			images.Write(make([]byte, padding))
+		}
+	}
+
+	var db bytes.Buffer
+	db.WriteString("GVEW0100JPEG0100")
+	u32(&db, grid*tilePitch)
+	u32(&db, grid*tilePitch)
+	db.WriteString("BLK_")
+	u32(&db, table.Len())
+	db.Write([]byte{0, 0, 0, 1, 0, 0, 0, 0})
+	u32(&db, 0x20)
+	u32(&db, 4)
+	db.Write(table.Bytes())
+	db.WriteString("BLK_")
+	u32(&db, images.Len())
+	db.Write([]byte{0, 0, 0, 2, 0, 0, 0, 0})
+	db.Write(images.Bytes())
+
+	// The names (16 bytes each) and the databases follow the page table.
+	firstPart := 16 + pages*16
+	var book bytes.Buffer
+	book.WriteString("TGDT0100")
+	u32(&book, pages)
+	u32(&book, firstPart)
+	for i := 0; i < pages; i++ {
+		u32(&book, i*16)
+		u32(&book, len("p000.gvd"))
+		u32(&book, pages*16+i*db.Len())
+		u32(&book, db.Len())
+	}
+	for i := 0; i < pages; i++ {
+		name := fmt.Sprintf("p%03d.gvd", i+1)
+		book.WriteString(name)
+		book.Write(make([]byte, 16-len(name)))
+	}
+	for i := 0; i < pages; i++ {
+		book.Write(db.Bytes())
+	}
+
+	filePath := path.Join(tb.TempDir(), "gvd.dat")
+	err := os.WriteFile(filePath, book.Bytes(), 0644)
+	if err != nil {
+		tb.Fatalf("unable to write book: %v", err)
+	}
+	return filePath
+}
+
+func BenchmarkExportPages(b *testing.B) {
+	filePath := writeTestBook(b, 8, 4)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		e := New(Options{MergeImages: true, ValidateOnly: true, TargetLayer: -1})
+		err := e.ExtractFile(filePath)
+		if err != nil {
+			b.Fatalf("unable to extract: %v", err)
+		}
+		if e.Stats.DecodedTiles != 8*4*4 {
+			b.Fatalf("decoded %v tiles, expected %v", e.Stats.DecodedTiles, 8*4*4)
+		}
+	}
+	b.StopTimer()
+
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+}
+
+func TestIsSingleTilePage(t *testing.T) {
+	tile := func(x, y, layer, size int) ImageInfo {
+		return ImageInfo{GridPosW: x, GridPosH: y, Width: size, Height: size, Layer: layer}
+	}
+
+	tests := []struct {
+		name      string
+		images    []ImageInfo
+		pageLayer int
+		want      bool
+	}{
+		{name: "no tiles", images: nil, pageLayer: -1, want: false},
+		{name: "single tile", images: []ImageInfo{tile(0, 0, 0, 256)}, pageLayer: -1, want: true},
+		{name: "smaller tile", images: []ImageInfo{tile(0, 0, 0, 128)}, pageLayer: -1, want: false},
+		{name: "moved tile", images: []ImageInfo{tile(1, 0, 0, 256)}, pageLayer: -1, want: false},
+		{name: "two layers", images: []ImageInfo{tile(0, 0, 0, 256), tile(0, 0, 1, 256)}, pageLayer: -1, want: false},
+		{name: "one of two layers", images: []ImageInfo{tile(0, 0, 0, 256), tile(0, 0, 1, 256)}, pageLayer: 1, want: true},
+	}
+	for _, test := range tests {
+		f := &File{Pages: []PageInfo{{Images: test.images}}}
+		if got := f.isSingleTilePage(0, test.pageLayer, 256, 256); got != test.want {
+			t.Errorf("%v: isSingleTilePage = %v, expected %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUndecodableTileSkipsPadding(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// Break the first tile, the regular images follow the BLK_ section header of the images.
+	book, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	first := bytes.LastIndex(book, []byte("BLK_")) + 16
+	if !bytes.HasPrefix(book[first:], jpegMagic) {
+		t.Fatalf("no jpeg at 0x%X", first)
+	}
+	copy(book[first:], "broken")
+	err = os.WriteFile(filePath, book, 0644)
+	if err != nil {
+		t.Fatalf("unable to write book: %v", err)
+	}
+
+	if padding := (16 - len(testJPEG(t))%16) % 16; padding == 0 {
+		t.Fatalf("test tiles have no padding")
+	}
+
+	e := New(Options{MergeImages: true, ValidateOnly: true})
+	err = e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if e.Stats.DecodedTiles != 3 || e.Stats.FailedTiles != 1 {
+		t.Errorf("got %v decoded and %v failed tiles, expected 3 and 1", e.Stats.DecodedTiles, e.Stats.FailedTiles)
+	}
+}
+
+func TestExportedPages(t *testing.T) {
+	filePath := writeTestBook(t, 3, 1)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// Pages without the requested layer export nothing and are not counted.
+	outDir := t.TempDir()
+	e := New(Options{MergeImages: true, OutDir: outDir, TargetLayer: 5})
+	err := e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if e.Stats.ExportedPages != 0 {
+		t.Errorf("got %v exported pages, expected 0", e.Stats.ExportedPages)
+	}
+
+	// Progress is only recorded for -resume.
+	e = New(Options{MergeImages: true, OutDir: outDir})
+	err = e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if e.Stats.ExportedPages != 3 {
+		t.Errorf("got %v exported pages, expected 3", e.Stats.ExportedPages)
+	}
+	if _, err := os.Stat(path.Join(outDir, progressFileName)); err == nil {
+		t.Errorf("progress recorded without resume")
+	}
+
+	for _, expected := range []int{3, 0} {
+		e = New(Options{MergeImages: true, OutDir: outDir, Resume: true})
+		err = e.ExtractFile(filePath)
+		if err != nil {
+			t.Fatalf("unable to extract: %v", err)
+		}
+		if e.Stats.ExportedPages != expected {
+			t.Errorf("got %v exported pages on resume, expected %v", e.Stats.ExportedPages, expected)
+		}
+	}
+}
+
+func TestClipSkipsTiles(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// Only the first tile overlaps the clip, unless every tile is needed for another output.
+	clip := image.Rect(0, 0, tilePitch/2, tilePitch/2)
+	onImage := func(pageName string, index int, img image.Image) {}
+	tests := []struct {
+		options  Options
+		expected int
+	}{
+		{options: Options{MergeImages: true, ValidateOnly: true, Clip: clip}, expected: 1},
+		{options: Options{MergeImages: true, ValidateOnly: true, Clip: clip, OnImage: onImage}, expected: 4},
+		{options: Options{MergeImages: true, ValidateOnly: true, Clip: clip, ExportTiles: true}, expected: 4},
+	}
+	for _, test := range tests {
+		e := New(test.options)
+		err := e.ExtractFile(filePath)
+		if err != nil {
+			t.Fatalf("unable to extract: %v", err)
+		}
+		if e.Stats.DecodedTiles != test.expected || e.Stats.FailedTiles != 0 {
+			t.Errorf("got %v decoded and %v failed tiles, expected %v and 0", e.Stats.DecodedTiles, e.Stats.FailedTiles, test.expected)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package playview
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"slices"
+)
+
+// fillMissingCells fills the grid cells of a layer that have no tile from the nearest other layer covering them,
+// scaled to the resolution of the layer (used by -fill-from-layer). It returns the number of filled cells.
+func (f *File) fillMissingCells(i int, layer int, canvas *image.RGBA, imagesStart int64, imagesEnd int64, pitchW int, pitchH int) int {
+	page := f.Pages[i]
+
+	// Grid cells of the layer with a tile.
+	present := map[image.Point]bool{}
+	cells := image.Point{}
+	for _, img := range page.Images {
+		if img.Layer == layer {
+			present[image.Pt(img.GridPosW, img.GridPosH)] = true
+			cells.X = max(cells.X, img.GridPosW+1)
+			cells.Y = max(cells.Y, img.GridPosH+1)
+		}
+	}
+	extent := f.layerExtent(page, layer, pitchW, pitchH).Intersect(canvas.Bounds())
+	if extent.Empty() {
+		return 0
+	}
+
+	var missing []image.Rectangle
+	for y := 0; y < cells.Y; y++ {
+		for x := 0; x < cells.X; x++ {
+			if present[image.Pt(x, y)] {
+				continue
+			}
+			cellX, cellY := x*pitchW+f.GridOffsetX, y*pitchH+f.GridOffsetY
+			cell := image.Rect(cellX, cellY, cellX+pitchW, cellY+pitchH).Intersect(extent)
+			if !cell.Empty() {
+				missing = append(missing, cell)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return 0
+	}
+
+	// Try the other layers by distance, the higher resolution first on a tie.
+	var fallbacks []int
+	for _, other := range page.Layers() {
+		if other != layer {
+			fallbacks = append(fallbacks, other)
+		}
+	}
+	slices.SortStableFunc(fallbacks, func(a int, b int) int {
+		return abs(a-layer) - abs(b-layer)
+	})
+
+	// Layers merged and scaled to the extent of the layer, built on first use.
+	scaledLayers := map[int]*image.RGBA{}
+
+	filled := 0
+	for _, cell := range missing {
+		for _, other := range fallbacks {
+			scaled, exists := scaledLayers[other]
+			if !exists {
+				scaled = f.mergeFallbackLayer(i, other, imagesStart, imagesEnd, pitchW, pitchH, extent.Dx(), extent.Dy())
+				scaledLayers[other] = scaled
+			}
+			if scaled == nil {
+				continue
+			}
+
+			source := cell.Sub(extent.Min)
+			if !hasOpaquePixel(scaled, source) {
+				continue
+			}
+			draw.Draw(canvas, cell, scaled, source.Min, draw.Over)
+			filled++
+			break
+		}
+	}
+
+	return filled
+}
+
+// mergeFallbackLayer decodes and merges the tiles of a layer and scales the area covered by them to width x height,
+// or returns nil if none of its tiles could be decoded. The file position is kept.
+func (f *File) mergeFallbackLayer(i int, layer int, imagesStart int64, imagesEnd int64, pitchW int, pitchH int, width int, height int) *image.RGBA {
+	resume, _ := f.handle.Seek(0, 1)
+	defer f.handle.Seek(resume, 0)
+
+	page := f.Pages[i]
+	extent := f.layerExtent(page, layer, pitchW, pitchH)
+	if extent.Empty() {
+		return nil
+	}
+	merged := image.NewRGBA(extent)
+
+	decoded := false
+	offsets := tileOffsets(page.Images)
+	for j, img := range page.Images {
+		if img.Layer != layer {
+			continue
+		}
+		data, found := f.tileData(page, j, imagesStart+offsets[j], imagesEnd)
+		if !found {
+			continue
+		}
+		tile, err := decodeImage(data, f.JPEGDecoder)
+		if err != nil {
+			continue
+		}
+		decoded = true
+
+		x, y := img.GridPosW*pitchW+f.GridOffsetX, img.GridPosH*pitchH+f.GridOffsetY
+		bounds := tile.Bounds()
+		draw.Draw(merged, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), tile, bounds.Min, draw.Over)
+	}
+	if !decoded {
+		return nil
+	}
+
+	return ResampleImage(merged, width, height, f.Resample)
+}
+
+// tileData reads the image data of tile j of a page starting at offset, the first (or with LoadFullImages the second)
+// image of a dual image. It returns false if the tile does not lie within the embedded images.
+func (f *File) tileData(page PageInfo, j int, offset int64, imagesEnd int64) ([]byte, bool) {
+	length := int64(page.Images[j].FileLength)
+	if offset+length > imagesEnd || (f.MaxTileBytes > 0 && page.Images[j].FileLength > f.MaxTileBytes) {
+		return nil, false
+	}
+	data := make([]byte, length)
+	_, err := f.handle.Seek(offset, 0)
+	if err != nil {
+		return nil, false
+	}
+	err = f.readFull(data)
+	if err != nil {
+		return nil, false
+	}
+	if page.ImageType != "gvmp" {
+		return data, true
+	}
+
+	// [Dual Image] The lengths follow the 12 bytes of "GVMP" and the two unknown fields, the images the 32 byte header.
+	if len(data) < 32 {
+		return nil, false
+	}
+	imageLength := int64(binary.BigEndian.Uint32(data[12:]))
+	paddedImageLength := int64(binary.BigEndian.Uint32(data[16:]))
+	secondImageLength := int64(binary.BigEndian.Uint32(data[20:]))
+	start, end := int64(32), 32+imageLength
+	if f.LoadFullImages && paddedImageLength != 32 {
+		start, end = paddedImageLength, paddedImageLength+secondImageLength
+	}
+	if end > int64(len(data)) || start > end {
+		return nil, false
+	}
+	return data[start:end], true
+}
+
+// layerExtent returns the area covered by the tiles of a layer in the merged image.
+func (f *File) layerExtent(page PageInfo, layer int, pitchW int, pitchH int) image.Rectangle {
+	extent := image.Rectangle{}
+	for _, img := range page.Images {
+		if img.Layer == layer {
+			x, y := img.GridPosW*pitchW+f.GridOffsetX, img.GridPosH*pitchH+f.GridOffsetY
+			extent = extent.Union(image.Rect(x, y, x+img.Width, y+img.Height))
+		}
+	}
+	return extent
+}
+
+// hasOpaquePixel checks whether any pixel within r is not fully transparent.
+func hasOpaquePixel(img *image.RGBA, r image.Rectangle) bool {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Glyphs of a tiny 3x5 pixel font, row by row from the top.
+var glyphs = map[rune]string{
+	'A': "010101111101101", 'B': "110101110101110", 'C': "011100100100011", 'D': "110101101101110",
+	'E': "111100110100111", 'F': "111100110100100", 'G': "011100101101011", 'H': "101101111101101",
+	'I': "111010010010111", 'J': "001001001101010", 'K': "101101110101101", 'L': "100100100100111",
+	'M': "101111111101101", 'N': "110101101101101", 'O': "010101101101010", 'P': "110101110100100",
+	'Q': "010101101110011", 'R': "110101110101101", 'S': "011100010001110", 'T': "111010010010010",
+	'U': "101101101101111", 'V': "101101101101010", 'W': "101101111111101", 'X': "101101010101101",
+	'Y': "101101010010010", 'Z': "111001010100111",
+	'0': "111101101101111", '1': "010110010010111", '2': "110001010100111", '3': "110001010001110",
+	'4': "101101111001001", '5': "111100110001110", '6': "011100111101111", '7': "111001010010010",
+	'8': "111101111101111", '9': "111101111001110",
+	'_': "000000000000111", '-': "000000111000000", '.': "000000000000010", ' ': "000000000000000",
+	'?': "110001010000010",
+}
+
+const glyphWidth = 3
+
+// GlyphHeight is the height in pixels of the font at scale 1.
+const GlyphHeight = 5
+
+// TextWidth returns the width in pixels of a text drawn with DrawText.
+func TextWidth(text string, scale int) int {
+	return len([]rune(text)) * (glyphWidth + 1) * scale
+}
+
+// DrawText renders a text in upper case with the tiny font, the top left corner at x, y.
+func DrawText(dst *image.RGBA, x int, y int, text string, scale int, c color.Color) {
+	for _, r := range strings.ToUpper(text) {
+		glyph, exists := glyphs[r]
+		if !exists {
+			glyph = glyphs['?']
+		}
+		for n, bit := range glyph {
+			if bit != '1' {
+				continue
+			}
+			gx, gy := x+(n%glyphWidth)*scale, y+(n/glyphWidth)*scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					dst.Set(gx+dx, gy+dy, c)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
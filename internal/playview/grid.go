@@ -0,0 +1,55 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"path"
+	"strconv"
+)
+
+// tilePlacement is the destination of a tile in the merged image (used by -debug-grid).
+type tilePlacement struct {
+	index int
+	layer int
+	rect  image.Rectangle
+}
+
+// Colors of the tile outlines, cycled by tile index so neighbouring tiles differ.
+var gridColors = []color.Color{
+	color.RGBA{R: 0xFF, A: 0xFF},
+	color.RGBA{G: 0xFF, A: 0xFF},
+	color.RGBA{B: 0xFF, A: 0xFF},
+	color.RGBA{R: 0xFF, B: 0xFF, A: 0xFF},
+}
+
+// writeDebugGrid stores a copy of a merged image as <OutDir>/<name>_grid.png, with the outline and the index of every
+// tile of the layer drawn on top.
+func (f *File) writeDebugGrid(name string, canvas *image.RGBA, layer int, placements []tilePlacement) error {
+	overlay := image.NewRGBA(canvas.Bounds())
+	draw.Draw(overlay, overlay.Bounds(), canvas, canvas.Bounds().Min, draw.Src)
+
+	for _, placement := range placements {
+		if f.SeparateLayers && placement.layer != layer {
+			continue
+		}
+		c := gridColors[placement.index%len(gridColors)]
+		drawOutline(overlay, placement.rect, c)
+		DrawText(overlay, placement.rect.Min.X+2, placement.rect.Min.Y+2, strconv.Itoa(placement.index), 1, c)
+	}
+
+	return f.writeSlotPNG(path.Join(f.OutDir, fmt.Sprintf("%v_grid.png", name)), overlay)
+}
+
+// drawOutline draws a 1px rectangle along the inner edge of r.
+func drawOutline(dst *image.RGBA, r image.Rectangle, c color.Color) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		dst.Set(x, r.Min.Y, c)
+		dst.Set(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		dst.Set(r.Min.X, y, c)
+		dst.Set(r.Max.X-1, y, c)
+	}
+}
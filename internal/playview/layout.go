@@ -0,0 +1,163 @@
+package playview
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Layout describes where the parser found the fields of the header and the first page of a file, so format notes can
+// be checked against what is actually read (used by -layout).
+type Layout struct {
+	File     string          `json:"file"`
+	Size     int64           `json:"size"`
+	Sections []LayoutSection `json:"sections"`
+}
+
+// LayoutSection is a group of consecutive fields. Repeated sections, like the entries of a table, are described
+// once, Repeat tells how often they occur and Stride how far apart they are.
+type LayoutSection struct {
+	Name   string        `json:"name"`
+	Offset int64         `json:"offset"`
+	Length int64         `json:"length"`
+	Repeat int           `json:"repeat,omitempty"`
+	Stride int64         `json:"stride,omitempty"`
+	Fields []LayoutField `json:"fields"`
+}
+
+// LayoutField is a single field with its absolute offset in the file, the value the parser took from it and its raw
+// bytes (only up to maxLayoutHexLength bytes).
+type LayoutField struct {
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Value  any    `json:"value"`
+	Hex    string `json:"hex,omitempty"`
+}
+
+// Longest field whose raw bytes are part of the layout.
+const maxLayoutHexLength = 16
+
+// DescribeLayout parses the header and the image table of the first page of a file and returns the offsets, lengths
+// and values of their fields.
+func (e *Extractor) DescribeLayout(filePath string) (layout Layout, err error) {
+	f, err := e.Open(filePath)
+	if err != nil {
+		return Layout{}, err
+	}
+	defer f.Close()
+
+	// The image table of a broken page panics like during an extraction.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to read the first page: %v", r)
+		}
+	}()
+
+	layout.File = filePath
+	layout.Size, _ = f.handle.Seek(0, 2)
+
+	w := int64(headerFieldWidth)
+	header := LayoutSection{Name: "header", Offset: f.base}
+	header.add("signature", 8, "TGDT0100")
+	header.add("page count", w, f.totalDataEntries)
+	header.add("length of the first part", w, f.totalLengthFirstPart)
+	layout.Sections = append(layout.Sections, header)
+
+	if len(f.Pages) == 0 {
+		return layout, f.fillLayoutHex(&layout)
+	}
+	page := f.Pages[0]
+
+	entry := LayoutSection{Name: "page table entry", Offset: header.Offset + header.Length, Repeat: len(f.Pages), Stride: 4 * w}
+	entry.add("file name offset", w, page.OffsetFileName)
+	entry.add("file name length", w, page.LengthFileName)
+	entry.add("database offset", w, page.OffsetDataBaseViewer)
+	entry.add("database length", w, page.LengthDataBaseViewer)
+	layout.Sections = append(layout.Sections, entry)
+
+	fileName := LayoutSection{Name: "file name of page 0", Offset: f.base + f.totalLengthFirstPart + page.OffsetFileName}
+	fileName.add("file name", int64(page.LengthFileName), page.FileName+".gvd")
+	layout.Sections = append(layout.Sections, fileName)
+
+	f.readImageTable(0)
+	page = f.Pages[0]
+	imagesStart, _ := f.handle.Seek(0, 1)
+
+	database := LayoutSection{Name: "database of page 0", Offset: f.base + f.totalLengthFirstPart + page.OffsetDataBaseViewer}
+	database.add("key", 16, page.ImageType)
+	database.add("page width", 4, page.ImageWidth)
+	database.add("page height", 4, page.ImageHeight)
+	database.add("section marker", 4, "BLK_")
+	database.add("image table length", 4, page.LengthDatabase)
+	database.add("image table start", 8, "00 00 00 01 00 00 00 00")
+	database.add("entry length", 4, page.EntranceLength)
+	database.add("parameter length", 4, page.ParamLength)
+	layout.Sections = append(layout.Sections, database)
+
+	if len(page.Images) > 0 {
+		tile := page.Images[0]
+		table := LayoutSection{Name: "image table entry", Offset: database.Offset + imageTableOffset, Repeat: len(page.Images), Stride: int64(page.EntranceLength)}
+		table.add("grid position x", 4, tile.GridPosW)
+		table.add("grid position y", 4, tile.GridPosH)
+		table.add("layer", 4, tile.Layer)
+		table.add("image length", 4, tile.FileLength)
+		table.add("image padding", 4, tile.FileLengthPadding)
+		table.add("field 0044", 4, tile.Reserved)
+		table.add("image width", 4, tile.Width)
+		table.add("image height", 4, tile.Height)
+		layout.Sections = append(layout.Sections, table)
+	}
+
+	images := LayoutSection{Name: "image section of page 0", Offset: imagesStart}
+	images.add("section marker", 4, "BLK_")
+	lengthImages, err := f.readOffsetAt(imagesStart+4, 4)
+	if err != nil {
+		return Layout{}, err
+	}
+	images.add("length of the images", 4, lengthImages)
+	images.add("images start", 8, "00 00 00 02 00 00 00 00")
+	if len(page.Images) > 0 {
+		images.add("first image", int64(page.Images[0].FileLength), page.ImageType)
+	}
+	layout.Sections = append(layout.Sections, images)
+
+	return layout, f.fillLayoutHex(&layout)
+}
+
+// add appends a field directly after the last field of the section.
+func (s *LayoutSection) add(name string, length int64, value any) {
+	s.Fields = append(s.Fields, LayoutField{Name: name, Offset: s.Offset + s.Length, Length: length, Value: value})
+	s.Length += length
+}
+
+// readOffsetAt reads an n bytes wide field at an absolute offset.
+func (f *File) readOffsetAt(offset int64, n int) (int64, error) {
+	_, err := f.handle.Seek(offset, 0)
+	if err != nil {
+		return 0, fmt.Errorf("unable to seek: %v", err)
+	}
+	return f.readOffsetN(n)
+}
+
+// fillLayoutHex adds the raw bytes of the short fields of a layout.
+func (f *File) fillLayoutHex(layout *Layout) error {
+	for s := range layout.Sections {
+		for n, field := range layout.Sections[s].Fields {
+			if field.Length > maxLayoutHexLength {
+				continue
+			}
+			_, err := f.handle.Seek(field.Offset, 0)
+			if err != nil {
+				return fmt.Errorf("unable to seek: %v", err)
+			}
+			raw := make([]byte, field.Length)
+			_, err = io.ReadFull(f.handle, raw)
+			if err != nil {
+				return fmt.Errorf("unable to read %v at 0x%X: %v", field.Name, field.Offset, err)
+			}
+			layout.Sections[s].Fields[n].Hex = hex.EncodeToString(raw)
+		}
+	}
+	return nil
+}
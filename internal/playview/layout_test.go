@@ -0,0 +1,43 @@
+package playview
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestDescribeLayout(t *testing.T) {
+	filePath := writeTestBook(t, 2, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	layout, err := New(Options{}).DescribeLayout(filePath)
+	if err != nil {
+		t.Fatalf("unable to describe layout: %v", err)
+	}
+
+	sections := map[string]LayoutSection{}
+	for _, section := range layout.Sections {
+		sections[section.Name] = section
+		for _, field := range section.Fields {
+			if field.Length <= maxLayoutHexLength && len(field.Hex) != 2*int(field.Length) {
+				t.Errorf("%v/%v: hex %q does not cover %v bytes", section.Name, field.Name, field.Hex, field.Length)
+			}
+		}
+	}
+
+	if got := sections["page table entry"]; got.Repeat != 2 || got.Offset != 16 {
+		t.Errorf("page table at %v with %v entries, expected 16 and 2", got.Offset, got.Repeat)
+	}
+	if got := sections["image table entry"]; got.Repeat != 4 || got.Stride != 32 {
+		t.Errorf("image table with %v entries of %v bytes, expected 4 of 32", got.Repeat, got.Stride)
+	}
+	for _, name := range []string{"database of page 0", "image section of page 0"} {
+		for _, field := range sections[name].Fields {
+			if field.Name == "section marker" && field.Hex != "424c4b5f" {
+				t.Errorf("%v: section marker is %v, expected BLK_", name, field.Hex)
+			}
+		}
+	}
+}
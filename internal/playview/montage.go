@@ -0,0 +1,97 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"path"
+	"slices"
+)
+
+// Size of a tile cell of the montage, the tiles are at most as large as the grid stride.
+const montageCell = tilePitch
+
+// Space around each tile and the scale of the index font.
+const montagePadding = 4
+const montageCaptionScale = 2
+
+// montageTile is a tile of the montage, img is nil if it could not be decoded (used by -tile-montage).
+type montageTile struct {
+	index int
+	info  ImageInfo
+	img   image.Image
+}
+
+// writeTileMontages stores one <OutDir>/<page>_layer_<n>_tiles.png per layer, showing the individual tiles of the
+// layer in grid order with their index. Undecodable tiles are crossed out.
+func (f *File) writeTileMontages(name string, tiles []montageTile) error {
+	byLayer := map[int][]montageTile{}
+	var layers []int
+	for _, tile := range tiles {
+		if _, exists := byLayer[tile.info.Layer]; !exists {
+			layers = append(layers, tile.info.Layer)
+		}
+		byLayer[tile.info.Layer] = append(byLayer[tile.info.Layer], tile)
+	}
+	slices.Sort(layers)
+
+	for _, layer := range layers {
+		layerTiles := byLayer[layer]
+		slices.SortStableFunc(layerTiles, func(a montageTile, b montageTile) int {
+			if a.info.GridPosH != b.info.GridPosH {
+				return a.info.GridPosH - b.info.GridPosH
+			}
+			return a.info.GridPosW - b.info.GridPosW
+		})
+
+		// One column per grid column of the layer.
+		columns := 1
+		for _, tile := range layerTiles {
+			columns = max(columns, tile.info.GridPosW+1)
+		}
+		columns = min(columns, len(layerTiles))
+		rows := (len(layerTiles) + columns - 1) / columns
+
+		captionHeight := (GlyphHeight + 2) * montageCaptionScale
+		cellWidth := montageCell + 2*montagePadding
+		cellHeight := montageCell + captionHeight + 2*montagePadding
+
+		sheet := image.NewRGBA(image.Rect(0, 0, columns*cellWidth, rows*cellHeight))
+		draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+		for n, tile := range layerTiles {
+			x := (n%columns)*cellWidth + montagePadding
+			y := (n/columns)*cellHeight + montagePadding
+			cell := image.Rect(x, y, x+montageCell, y+montageCell)
+
+			if tile.img != nil {
+				img := tile.img
+				if bounds := img.Bounds(); bounds.Dx() > montageCell || bounds.Dy() > montageCell {
+					width, height := FitSize(bounds.Dx(), bounds.Dy(), montageCell, montageCell)
+					img = ScaleImage(img, width, height)
+				}
+				bounds := img.Bounds()
+				draw.Draw(sheet, bounds.Sub(bounds.Min).Add(cell.Min), img, bounds.Min, draw.Over)
+			} else {
+				red := color.RGBA{R: 0xFF, A: 0xFF}
+				drawOutline(sheet, cell, red)
+				for d := 0; d < montageCell; d++ {
+					sheet.Set(x+d, y+d, red)
+					sheet.Set(x+montageCell-1-d, y+d, red)
+				}
+			}
+
+			// Same as the tile names: index_x_y.
+			caption := fmt.Sprintf("%v_%v_%v", tile.index, tile.info.GridPosW, tile.info.GridPosH)
+			DrawText(sheet, x, y+montageCell+montageCaptionScale, caption, montageCaptionScale, color.Black)
+		}
+
+		err := f.writeSlotPNG(path.Join(f.OutDir, fmt.Sprintf("%v_layer_%v_tiles.png", name, layer)), sheet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
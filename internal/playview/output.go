@@ -0,0 +1,137 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Output stores the files of an extraction by their name relative to the output location, e.g. "p001.png".
+type Output interface {
+	Create(name string) (OutputWriter, error)
+}
+
+// OutputWriter receives the data of a single file. Close completes the file, Abort discards it after an error.
+type OutputWriter interface {
+	io.Writer
+	Close() error
+	Abort()
+}
+
+// OutputFactory opens the Output for a location like file:///srv/pages.
+type OutputFactory func(location *url.URL) (Output, error)
+
+// Outputs by URL scheme. Backends for object storage register themselves behind build tags.
+var outputs = map[string]OutputFactory{
+	"file": openFileOutput,
+}
+
+// RegisterOutput adds the Output for locations with the given URL scheme.
+func RegisterOutput(scheme string, factory OutputFactory) {
+	outputs[scheme] = factory
+}
+
+// OpenOutput opens the Output for a location given as a local folder, a .cbz archive or as a URL like
+// file:///srv/pages.
+func OpenOutput(location string) (Output, error) {
+	if IsArchiveOutput(location) {
+		archive, err := CreateZipOutput(location)
+		if err != nil {
+			return nil, err
+		}
+		return archive, nil
+	}
+	if !strings.Contains(location, "://") {
+		return FileOutput{Dir: location}, nil
+	}
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output location %v: %v", location, err)
+	}
+	factory, exists := outputs[parsed.Scheme]
+	if !exists {
+		return nil, fmt.Errorf("unsupported output scheme: %v", parsed.Scheme)
+	}
+	return factory(parsed)
+}
+
+// IsArchiveOutput checks whether a location is a .cbz archive collecting the output.
+func IsArchiveOutput(location string) bool {
+	return !strings.Contains(location, "://") && strings.EqualFold(path.Ext(location), ".cbz")
+}
+
+// WriterSink encodes every image as <pageName>.png into an Output, with a pHYs chunk for the dpi unless it is 0.
+func WriterSink(output Output, dpi int) ImageSink {
+	return func(pageName string, img image.Image) error {
+		w, err := output.Create(fmt.Sprintf("%v.png", pageName))
+		if err != nil {
+			return err
+		}
+		return encodePNG(w, img, dpi)
+	}
+}
+
+// FileOutput writes the files into a local folder. Each file is written to a temporary file first, so an interrupted
+// run never leaves a truncated file behind.
+type FileOutput struct {
+	Dir string
+}
+
+// openFileOutput opens the folder of a file:// URL, file://out is relative to the working directory.
+func openFileOutput(location *url.URL) (Output, error) {
+	dir := location.Host + location.Path
+	if len(dir) > 2 && dir[0] == '/' && dir[2] == ':' {
+		// A Windows drive like file:///C:/pages.
+		dir = dir[1:]
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("output location %v has no folder", location)
+	}
+	return FileOutput{Dir: filepath.FromSlash(dir)}, nil
+}
+
+func (o FileOutput) Create(name string) (OutputWriter, error) {
+	return createPartFile(path.Join(o.Dir, name))
+}
+
+// partFile is written as <filePath>.part and moved to filePath when it is closed.
+type partFile struct {
+	*os.File
+	filePath string
+}
+
+// createPartFile creates the temporary file for filePath and the folder it is written to.
+func createPartFile(filePath string) (*partFile, error) {
+	err := createParentDir(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filePath + ".part")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %v", err)
+	}
+	return &partFile{File: file, filePath: filePath}, nil
+}
+
+func (f *partFile) Close() error {
+	closeErr := f.File.Close()
+	if closeErr != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+	err := os.Rename(f.Name(), f.filePath)
+	if err != nil {
+		return fmt.Errorf("unable to move output file: %v", err)
+	}
+	return nil
+}
+
+func (f *partFile) Abort() {
+	_ = f.File.Close()
+	_ = os.Remove(f.Name())
+}
@@ -0,0 +1,96 @@
+package playview
+
+import (
+	"bytes"
+	"image"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memoryOutput keeps the completed files in memory.
+type memoryOutput map[string][]byte
+
+type memoryWriter struct {
+	bytes.Buffer
+	output memoryOutput
+	name   string
+}
+
+func (o memoryOutput) Create(name string) (OutputWriter, error) {
+	return &memoryWriter{output: o, name: name}, nil
+}
+
+func (w *memoryWriter) Close() error {
+	w.output[w.name] = w.Bytes()
+	return nil
+}
+
+func (w *memoryWriter) Abort() {}
+
+func TestOpenOutput(t *testing.T) {
+	tests := []struct {
+		location string
+		dir      string
+		wantErr  bool
+	}{
+		{location: "out", dir: "out"},
+		{location: "file:///srv/pages", dir: filepath.FromSlash("/srv/pages")},
+		{location: "file://out/pages", dir: filepath.FromSlash("out/pages")},
+		{location: "file:///C:/pages", dir: filepath.FromSlash("C:/pages")},
+		{location: "file://", wantErr: true},
+		{location: "s3://bucket/prefix", wantErr: true},
+	}
+	for _, test := range tests {
+		output, err := OpenOutput(test.location)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("OpenOutput(%q) = %v, expected an error", test.location, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("OpenOutput(%q) failed: %v", test.location, err)
+			continue
+		}
+		if fileOutput, isFile := output.(FileOutput); !isFile || fileOutput.Dir != test.dir {
+			t.Errorf("OpenOutput(%q) = %#v, expected the folder %v", test.location, output, test.dir)
+		}
+	}
+}
+
+func TestRegisterOutput(t *testing.T) {
+	files := memoryOutput{}
+	RegisterOutput("memory", func(location *url.URL) (Output, error) {
+		return files, nil
+	})
+	defer delete(outputs, "memory")
+
+	output, err := OpenOutput("memory://pages")
+	if err != nil {
+		t.Fatalf("unable to open output: %v", err)
+	}
+	err = WriterSink(output, 0)("p001", image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	if err != nil {
+		t.Fatalf("unable to write page: %v", err)
+	}
+	if !bytes.HasPrefix(files["p001.png"], []byte("\x89PNG")) {
+		t.Errorf("p001.png was not written as png: %q", files["p001.png"])
+	}
+}
+
+func TestFileOutputAbort(t *testing.T) {
+	dir := t.TempDir()
+	w, err := FileOutput{Dir: dir}.Create("broken.png")
+	if err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+	_, _ = w.Write([]byte("partial"))
+	w.Abort()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("aborted file left %v entries behind", len(entries))
+	}
+}
@@ -0,0 +1,89 @@
+package playview
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path"
+)
+
+// Magic at the start of every pixel dump.
+var pixelMagic = []byte{0x50, 0x56, 0x50, 0x58} // "PVPX"
+
+// PixelSink stores each image uncompressed as <dir>/<pageName>.bin (used by -pixels).
+//
+// The file starts with a 20 byte header: the magic "PVPX", then width, height, channels (always 4) and bytes per
+// channel (1, or 2 with BitDepth 16) as little endian uint32. The pixels follow row by row as non-premultiplied RGBA,
+// 16 bit channels are little endian.
+func PixelSink(dir string, bitDepth int) ImageSink {
+	return func(pageName string, img image.Image) error {
+		return writePixels(path.Join(dir, fmt.Sprintf("%v.bin", pageName)), img, bitDepth)
+	}
+}
+
+// writePixels writes the pixel dump of an image to filePath through a temporary file.
+func writePixels(filePath string, img image.Image, bitDepth int) error {
+	partPath := filePath + ".part"
+
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+
+	bytesPerChannel := 1
+	if bitDepth == 16 {
+		bytesPerChannel = 2
+	}
+	bounds := img.Bounds()
+
+	pixelFile, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %v", err)
+	}
+	writer := bufio.NewWriter(pixelFile)
+
+	header := make([]byte, 20)
+	copy(header, pixelMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(bounds.Dx()))
+	binary.LittleEndian.PutUint32(header[8:], uint32(bounds.Dy()))
+	binary.LittleEndian.PutUint32(header[12:], 4)
+	binary.LittleEndian.PutUint32(header[16:], uint32(bytesPerChannel))
+	_, _ = writer.Write(header)
+
+	row := make([]byte, bounds.Dx()*4*bytesPerChannel)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			n := (x - bounds.Min.X) * 4 * bytesPerChannel
+			if bytesPerChannel == 1 {
+				row[n], row[n+1], row[n+2], row[n+3] = uint8(c.R>>8), uint8(c.G>>8), uint8(c.B>>8), uint8(c.A>>8)
+			} else {
+				binary.LittleEndian.PutUint16(row[n:], c.R)
+				binary.LittleEndian.PutUint16(row[n+2:], c.G)
+				binary.LittleEndian.PutUint16(row[n+4:], c.B)
+				binary.LittleEndian.PutUint16(row[n+6:], c.A)
+			}
+		}
+		_, _ = writer.Write(row)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		_ = pixelFile.Close()
+		_ = os.Remove(partPath)
+		return fmt.Errorf("unable to write pixels: %v", err)
+	}
+	closeErr := pixelFile.Close()
+	if closeErr != nil {
+		_ = os.Remove(partPath)
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+	err = os.Rename(partPath, filePath)
+	if err != nil {
+		return fmt.Errorf("unable to move output file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,490 @@
+// Package playview parses PlayStation PlayView files (gvd.dat) and exports their pages.
+//
+// See README for more details.
+package playview
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"path"
+	"slices"
+)
+
+// Default grid stride of the tiles in pixels.
+const tilePitch = 256
+
+// Options configure an Extractor.
+type Options struct {
+	MergeImages    bool
+	ExportTiles    bool
+	TargetLayer    int
+	TargetPage     string
+	TargetPageGlob string
+	OutDir         string
+	LoadFullImages bool
+	SkipMergeRaw   bool
+	DedupTiles     bool
+	AutoPitch      bool
+	ValidateOnly   bool
+	RotateDegrees  int
+	FlipDirection  string
+	OverlapMode    string
+	Order          string
+	LayerOrder     string
+	SeparateLayers bool
+	AutoCrop       bool
+
+	// Swap the width and height of pages whose tiles cover the transposed page size.
+	AutoFix bool
+
+	WriteSidecar   bool
+	GridOffsetX    int
+	GridOffsetY    int
+	ZoomAnimation  bool
+	AlignLayers    bool
+	DebugGrid      bool
+	TileMontage    bool
+	MultiContainer bool
+	DumpBlocks     bool
+
+	// Pack the decoded tiles of every page into <page>_sprites.png with a TexturePacker <page>_sprites.json (used by
+	// -sprite-sheet).
+	SpriteSheet bool
+
+	// Write the database of every page verbatim as <page>.dbv (used by -dump-dbviewer).
+	DumpDatabaseViewer bool
+
+	// Amount of logging, LogQuiet to LogTrace (used by -v and -debug). The zero value logs the progress of every page.
+	LogLevel int
+
+	// Skip the pages recorded as completed in OutDir by an earlier run.
+	Resume      bool
+	ThumbsOnly  bool
+	JPEGDecoder string
+
+	// Skip tiles whose data is longer than this many bytes, as they come from a corrupt length field, 0 for no limit
+	// (used by -max-tile-bytes).
+	MaxTileBytes int
+
+	// Retry a failed read this often before giving up on the page, for files on network shares (used by
+	// -read-retries).
+	ReadRetries int
+
+	// Fill the grid cells missing in TargetLayer from the nearest other layer (used by -fill-from-layer).
+	FillFromLayer bool
+
+	// Only export the pages with GVMP dual images (used by -dual-only).
+	DualOnly bool
+
+	// Name the tiles of dual images <page>_layer_<n>_<A|B>_<index>_<x>_<y> by their layer and image (used by
+	// -dual-names).
+	DualTileNames bool
+
+	// Write the tile data unchanged as <page>/<layer>/<x>_<y>.jpg with a <page>/metadata.json of the grid extents
+	// instead of the single tiles, for tiled map viewers (used by -tms-layout).
+	TMSLayout bool
+
+	// Also write a <page>/index.html assembling the tiles of TMSLayout on a canvas in the browser (used by
+	// -html-viewer).
+	HTMLViewer bool
+
+	// Write the exported tiles with their embedded data unchanged as <tile>.jpg instead of decoding them to png files
+	// (used by -raw-tiles).
+	RawTiles bool
+
+	// Log every seek and read on the input with the resulting position (used by -trace-seeks).
+	TraceSeeks bool
+
+	// Log the bytes around the offset of a failed compare (used by -hexdump).
+	HexDump bool
+
+	// Hash every input file before parsing it and list the hashes in <OutDir>/source.sha256 and the sidecars (used by
+	// -source-hash).
+	SourceHash bool
+
+	// Carve the images out by their markers instead of parsing the file, for files with corrupt length fields (used by
+	// -scan-mode).
+	ScanMode bool
+
+	// Only warn about a wrong header or marker and parse anyway (used by -force).
+	Force bool
+
+	// Output name of each page with placeholders like {book}/{page}, empty to use the page name (used by -out-template).
+	OutTemplate string
+
+	// Crop merged pages to this region of the page, empty to keep the whole page (used by -clip).
+	Clip image.Rectangle
+
+	// Grid positions of the tiles to export, the zero value exports all (used by -tile-list).
+	TileList TileList
+
+	// Resampler for resized merged images: nearest (or empty), bilinear or catmullrom (used by -resample).
+	Resample string
+
+	// Resolution stored in the png files written by the default Sink, 0 to store none (used by -dpi).
+	DPI int
+
+	// Scale merged pages down to at most this width, 0 to keep their size (used by -preview-width).
+	PreviewWidth int
+
+	// Scale merged pages down to at most this many million pixels, keeping their aspect ratio, 0 to keep their size
+	// (used by -max-megapixels).
+	MaxMegapixels float64
+
+	// Make the near-white pixels of merged pages transparent, those whose color channels are all at most
+	// WhiteThreshold (0-255) below white (used by -white-to-alpha).
+	WhiteToAlpha   bool
+	WhiteThreshold int
+
+	// Also save the red, green, blue and alpha channel of every merged page as <page>_<r|g|b|a>.png grayscale images
+	// (used by -split-channels).
+	SplitChannels bool
+
+	// Bits per channel of the merged pages, 8 or 16 (used by -bitdepth). The tiles are 8-bit, so 16 only upsamples.
+	BitDepth int
+
+	// Letterbox merged pages to exactly FitWidth x FitHeight (used by -fit), 0 to keep their size.
+	FitWidth      int
+	FitHeight     int
+	FitBackground color.Color
+
+	// Write uncompressed pixel dumps instead of PNG files if no Sink is set (used by -pixels).
+	RawPixels bool
+
+	// Join each two consecutive merged pages side by side into <first>_<second> (used by -spread). With SpreadStart
+	// "even" the first page stays alone, SpreadRightToLeft puts the first page of a pair on the right, SpreadGutter is
+	// the gap between the pages in pixels.
+	Spread            bool
+	SpreadStart       string
+	SpreadRightToLeft bool
+	SpreadGutter      int
+
+	// Prefix the output names with a running page number (used for several input files).
+	NumberPages bool
+
+	// Bounds the number of files written at the same time, shared by all Extractors with the same options, nil for no
+	// bound (used by -write-jobs).
+	WriteSlots chan struct{}
+
+	// Sink receives each merged page, or each tile if merging is disabled. Defaults to writing PNG (or pixel dump) files
+	// into OutDir.
+	Sink ImageSink
+
+	// OnProgress is called at the start of each exported page, if set.
+	OnProgress func(pageIndex int, pageCount int, pageName string)
+
+	// OnImage is called for each decoded tile, if set.
+	OnImage func(pageName string, tileIndex int, img image.Image)
+
+	// OnPage is called for each merged page passed to the Sink, if set.
+	OnPage func(pageName string, img image.Image)
+}
+
+// Log levels of Options.LogLevel.
+const (
+	// Only warnings and errors.
+	LogQuiet = -1
+
+	// The progress of every page.
+	LogNormal = 0
+
+	// Also the fields of the header and the page databases.
+	LogVerbose = 1
+
+	// Also every tile and its position in the file.
+	LogTrace = 2
+)
+
+// ImageSink receives an exported image together with its output name (without extension).
+type ImageSink func(pageName string, img image.Image) error
+
+// Stats are collected over all files handled by an Extractor.
+type Stats struct {
+	DecodedTiles  int
+	FailedTiles   int
+	FailedPages   int
+	ExportedPages int
+	Warnings      int
+
+	// Pages with at least one exported image, by name.
+	ProducedPages map[string]bool
+
+	// Pages that were skipped after a panic while parsing them.
+	CrashedPages []string
+
+	// SHA-256 of the input files by path (used by -source-hash).
+	SourceHashes map[string]string
+}
+
+// Add sums up the stats of another Extractor, e.g. of a book extracted in parallel.
+func (s *Stats) Add(other Stats) {
+	s.DecodedTiles += other.DecodedTiles
+	s.FailedTiles += other.FailedTiles
+	s.FailedPages += other.FailedPages
+	s.ExportedPages += other.ExportedPages
+	s.Warnings += other.Warnings
+	s.CrashedPages = append(s.CrashedPages, other.CrashedPages...)
+	if s.ProducedPages == nil {
+		s.ProducedPages = map[string]bool{}
+	}
+	for name := range other.ProducedPages {
+		s.ProducedPages[name] = true
+	}
+	if s.SourceHashes == nil {
+		s.SourceHashes = map[string]string{}
+	}
+	for filePath, hash := range other.SourceHashes {
+		s.SourceHashes[filePath] = hash
+	}
+}
+
+// Extractor exports the pages of one or more files with the same options.
+type Extractor struct {
+	Options
+	Stats Stats
+
+	// Whether the Sink was set by New, so it keeps no image after the call.
+	builtinSink bool
+
+	// Exported tiles by the hash of their data (used by -dedup).
+	writtenTiles map[[sha256.Size]byte]string
+
+	// Number of the last numbered page across all input files (used by NumberPages).
+	pageNumber int
+
+	// Pages completed in this or an earlier run, by output name (used by -resume).
+	completedPages map[string]bool
+	progress       progressState
+	progressLoaded bool
+
+	// SHA-256 of the file being extracted, empty unless SourceHash is set.
+	sourceHash string
+
+	// Merged pages passed on so far and the one waiting for its pair (used by -spread).
+	spreadPages   int
+	pendingSpread *spreadPage
+}
+
+// New creates an Extractor.
+func New(options Options) *Extractor {
+	builtinSink := options.Sink == nil || options.ValidateOnly
+	if options.ValidateOnly {
+		// Decode everything but discard the output.
+		options.Sink = func(pageName string, img image.Image) error {
+			return nil
+		}
+	}
+	if options.Sink == nil && options.RawPixels {
+		options.Sink = PixelSink(options.OutDir, options.BitDepth)
+	}
+	if options.Sink == nil {
+		options.Sink = PNGSink(options.OutDir, options.DPI)
+	}
+	if options.FitBackground == nil {
+		options.FitBackground = color.White
+	}
+	if options.JPEGDecoder == "" {
+		options.JPEGDecoder = "std"
+	}
+	return &Extractor{
+		Options:        options,
+		builtinSink:    builtinSink,
+		Stats:          Stats{ProducedPages: map[string]bool{}},
+		writtenTiles:   map[[sha256.Size]byte]string{},
+		completedPages: map[string]bool{},
+	}
+}
+
+// Validate checks the option values.
+func (o Options) Validate() error {
+	if o.OverlapMode != "" && o.OverlapMode != "first" && o.OverlapMode != "last" && o.OverlapMode != "skip" {
+		return fmt.Errorf("invalid overlap mode: %v", o.OverlapMode)
+	}
+
+	if o.Order != "" && o.Order != "table" && o.Order != "name" {
+		return fmt.Errorf("invalid page order: %v", o.Order)
+	}
+
+	if o.LayerOrder != "" && o.LayerOrder != "file" && o.LayerOrder != "asc" && o.LayerOrder != "desc" {
+		return fmt.Errorf("invalid layer order: %v", o.LayerOrder)
+	}
+
+	if o.RotateDegrees != 0 && o.RotateDegrees != 90 && o.RotateDegrees != 180 && o.RotateDegrees != 270 {
+		return fmt.Errorf("invalid rotation: %v", o.RotateDegrees)
+	}
+
+	if o.FlipDirection != "" && o.FlipDirection != "h" && o.FlipDirection != "v" {
+		return fmt.Errorf("invalid flip direction: %v", o.FlipDirection)
+	}
+
+	if err := validateTemplate(o.OutTemplate); err != nil {
+		return fmt.Errorf("invalid output template: %v", err)
+	}
+
+	if o.BitDepth != 0 && o.BitDepth != 8 && o.BitDepth != 16 {
+		return fmt.Errorf("invalid bit depth: %v", o.BitDepth)
+	}
+
+	if o.DPI < 0 {
+		return fmt.Errorf("invalid dpi: %v", o.DPI)
+	}
+
+	if o.MaxTileBytes < 0 {
+		return fmt.Errorf("invalid maximum tile size: %v", o.MaxTileBytes)
+	}
+
+	if o.PreviewWidth < 0 {
+		return fmt.Errorf("invalid preview width: %v", o.PreviewWidth)
+	}
+
+	if o.MaxMegapixels < 0 {
+		return fmt.Errorf("invalid megapixel budget: %v", o.MaxMegapixels)
+	}
+
+	if o.WhiteThreshold < 0 || o.WhiteThreshold > 255 {
+		return fmt.Errorf("invalid white threshold: %v", o.WhiteThreshold)
+	}
+
+	if o.SpreadStart != "" && o.SpreadStart != "odd" && o.SpreadStart != "even" {
+		return fmt.Errorf("invalid spread start: %v", o.SpreadStart)
+	}
+
+	if o.SpreadGutter < 0 {
+		return fmt.Errorf("invalid spread gutter: %v", o.SpreadGutter)
+	}
+
+	if o.Spread && (!o.MergeImages || o.SeparateLayers || o.AlignLayers || o.ZoomAnimation) {
+		return fmt.Errorf("spreads need a single merged image per page")
+	}
+
+	if o.Spread && o.Resume {
+		return fmt.Errorf("spreads cannot be resumed, a page may be waiting for its pair")
+	}
+
+	if o.FitWidth < 0 || o.FitHeight < 0 || (o.FitWidth == 0) != (o.FitHeight == 0) {
+		return fmt.Errorf("invalid fit size: %vx%v", o.FitWidth, o.FitHeight)
+	}
+
+	if !IsResampler(o.Resample) {
+		return fmt.Errorf("unknown resampler: %v", o.Resample)
+	}
+
+	if _, exists := jpegDecoders[o.JPEGDecoder]; !exists {
+		return fmt.Errorf("unknown jpeg decoder: %v", o.JPEGDecoder)
+	}
+
+	if _, err := path.Match(o.TargetPageGlob, ""); err != nil {
+		return fmt.Errorf("invalid page pattern %v: %v", o.TargetPageGlob, err)
+	}
+
+	return nil
+}
+
+// IsTargetPage checks whether a page was requested by TargetPage and TargetPageGlob.
+func (o Options) IsTargetPage(fileName string) bool {
+	if o.TargetPage != "" && o.TargetPage != fileName {
+		return false
+	}
+	if o.TargetPageGlob != "" {
+		matched, err := path.Match(o.TargetPageGlob, fileName)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+type PageInfo struct {
+	// 0010 	4 	Offset file name.gvd (without header TGDT0100)
+	OffsetFileName int64
+
+	// 0014 	4 	Length file name.gvd (00 is not counted)
+	LengthFileName int
+
+	// 0018 	4 	Offset Data Base Viewer
+	OffsetDataBaseViewer int64
+
+	// 001C 	4 	Length Data base Viewer file
+	LengthDataBaseViewer int64
+
+	FileName string
+
+	// Title from an embedded table of contents, empty if there is none (see RegisterTOCParser).
+	Title string
+
+	// Name of the exported files (without extension).
+	OutputName string
+
+	ImageWidth     int
+	ImageHeight    int
+	LengthDatabase int
+
+	Images []ImageInfo
+
+	LengthImages   int64
+	ParamLength    int
+	EntranceLength int
+	ImageType      string
+
+	// Why the image table of the page could not be read, nil if it was (set by ReadStructure).
+	ReadError error
+
+	// Raw image table of a page whose parameter length has no known layout, the page has no Images then.
+	entryTable []byte
+}
+
+type ImageInfo struct {
+	GridPosW          int
+	GridPosH          int
+	Height            int
+	Width             int
+	FileLength        int
+	FileLengthPadding int
+	Layer             int
+
+	// Field at 0044, 0 in all known files.
+	Reserved int
+}
+
+// Layers returns the sorted set of layers used by the images of a page.
+func (p PageInfo) Layers() []int {
+	var layers []int
+	for _, img := range p.Images {
+		if !slices.Contains(layers, img.Layer) {
+			layers = append(layers, img.Layer)
+		}
+	}
+	slices.Sort(layers)
+	return layers
+}
+
+// TileMapping links the grid position of a tile to the exported (possibly shared) tile.
+type TileMapping struct {
+	Index int    `json:"index"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Layer int    `json:"layer"`
+	Name  string `json:"name"`
+
+	// Field at 0044 of the image table, see ImageInfo.
+	Reserved int `json:"reserved"`
+}
+
+// PageSidecar is the metadata written next to a merged page (used by -sidecar).
+type PageSidecar struct {
+	Name      string `json:"name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	ImageType string `json:"imageType"`
+	Tiles     int    `json:"tiles"`
+	Layers    []int  `json:"layers"`
+	Overlaps  int    `json:"overlaps"`
+
+	// Title from an embedded table of contents, if there is one.
+	Title string `json:"title,omitempty"`
+
+	// SHA-256 of the input file, if it was hashed (used by -source-hash).
+	SourceSHA256 string `json:"sourceSha256,omitempty"`
+}
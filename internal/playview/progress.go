@@ -0,0 +1,68 @@
+package playview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// Name of the state file in OutDir that records the completed pages.
+const progressFileName = ".playview-progress.json"
+
+// progressState is the content of the state file.
+type progressState struct {
+	Completed []string `json:"completed"`
+}
+
+// loadProgress reads the completed pages of an earlier run from the state file, if there is any (used by -resume).
+func (e *Extractor) loadProgress() error {
+	raw, err := os.ReadFile(path.Join(e.OutDir, progressFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read progress: %v", err)
+	}
+
+	var state progressState
+	err = json.Unmarshal(raw, &state)
+	if err != nil {
+		return fmt.Errorf("unable to decode progress: %v", err)
+	}
+
+	for _, name := range state.Completed {
+		e.completedPages[name] = true
+	}
+	e.progress = state
+
+	return nil
+}
+
+// markCompleted records a finished page by its output name and updates the state file (used by -resume).
+func (e *Extractor) markCompleted(outputName string) error {
+	if !e.Resume || e.ValidateOnly || e.completedPages[outputName] {
+		return nil
+	}
+	e.completedPages[outputName] = true
+	e.progress.Completed = append(e.progress.Completed, outputName)
+
+	raw, err := json.MarshalIndent(e.progress, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode progress: %v", err)
+	}
+
+	// Replace the state file at once, so an interrupted run never leaves it truncated.
+	filePath := path.Join(e.OutDir, progressFileName)
+	err = os.WriteFile(filePath+".part", raw, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write progress: %v", err)
+	}
+	err = os.Rename(filePath+".part", filePath)
+	if err != nil {
+		return fmt.Errorf("unable to move progress: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,678 @@
+package playview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// Width in bytes of the numeric fields in the header and the page table.
+var headerFieldWidth = 4
+
+// File is an opened gvd.dat with its header and page names read.
+type File struct {
+	*Extractor
+
+	handle input
+
+	// Offset of the container within the file and its number, 0 for the first (used by MultiContainer).
+	base      int64
+	container int
+
+	totalDataEntries     int
+	totalLengthFirstPart int64
+
+	Pages []PageInfo
+
+	// Name of the book, guessed from the path (used by OutTemplate).
+	book string
+
+	// Set while RenderPage runs, the merged page ends up in rendered.
+	rendering bool
+	rendered  image.Image
+
+	// Buffer for the data of the current tile, grown as needed (used by readScratch).
+	scratch []byte
+
+	// Grid positions merged on the current page, cleared for every page.
+	handled map[string]bool
+
+	// Position of the parser, used to give errors some context.
+	currentPage int
+	currentTile int
+}
+
+// Open reads the header and the page names of a gvd.dat, of a zip entry given as "archive.zip:gvd.dat", or of an http(s)
+// URL.
+func (e *Extractor) Open(filePath string) (*File, error) {
+	handle, err := e.openInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if e.TraceSeeks {
+		handle = tracedInput{handle}
+	}
+
+	f := &File{Extractor: e, handle: handle, book: BookName(filePath), currentPage: -1, currentTile: -1}
+
+	err = f.readHeader()
+	if err != nil {
+		_ = handle.Close()
+		return nil, err
+	}
+
+	err = f.readFileNames()
+	if err != nil {
+		_ = handle.Close()
+		return nil, err
+	}
+	f.readTitles()
+
+	return f, nil
+}
+
+// nextContainer opens the container that directly follows this one, if there is any.
+func (f *File) nextContainer() (*File, bool, error) {
+	end := int64(0)
+	for _, page := range f.Pages {
+		end = max(end, page.OffsetFileName+int64(page.LengthFileName), page.OffsetDataBaseViewer+page.LengthDataBaseViewer)
+	}
+	end += f.base + f.totalLengthFirstPart
+
+	// Containers may be aligned to 16 bytes.
+	for _, base := range []int64{end, end + (16-end%16)%16} {
+		_, _ = f.handle.Seek(base, 0)
+		signature, err := f.readString(8)
+		if err != nil || signature != "TGDT0100" {
+			continue
+		}
+
+		next := &File{Extractor: f.Extractor, handle: f.handle, base: base, container: f.container + 1, book: f.book, currentPage: -1, currentTile: -1}
+		_, _ = f.handle.Seek(base, 0)
+
+		f.logf("Container %v at offset 0x%X", next.container, base)
+
+		err = next.readHeader()
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read container %v: %w", next.container, err)
+		}
+		err = next.readFileNames()
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read container %v: %v", next.container, err)
+		}
+		next.readTitles()
+		return next, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// Close closes the underlying file.
+func (f *File) Close() error {
+	return f.handle.Close()
+}
+
+// FindPage returns the index of the page with the given name or -1.
+func (f *File) FindPage(name string) int {
+	for i := 0; i < f.totalDataEntries; i++ {
+		if f.Pages[i].FileName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReadStructure parses the header and all image tables of a file without exporting any images. A page whose image
+// table cannot be read is returned with its ReadError and without images.
+func (e *Extractor) ReadStructure(filePath string) ([]PageInfo, error) {
+	f, err := e.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	for i := 0; i < f.totalDataEntries; i++ {
+		err := f.readImageTableRecovered(i)
+		if err != nil {
+			f.Stats.FailedPages++
+			f.Pages[i].ReadError = err
+			f.Pages[i].Images = nil
+		}
+	}
+
+	return f.Pages, nil
+}
+
+// readImageTableRecovered reads the image table of page i like readImageTable, but a panic while parsing the page is
+// returned as an error.
+func (f *File) readImageTableRecovered(i int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to read the image table: %v", r)
+		}
+	}()
+	f.readImageTable(i)
+	return nil
+}
+
+// Image types by the key at the start of a page database.
+var imageTypes = map[string]string{
+	"GVEW0100JPEG0100": "jpeg",
+	"GVEW0100GVMP0100": "gvmp",
+}
+
+// Offset of the image table within the database of a page.
+const imageTableOffset = 0x30
+
+// peekImageType reads the image type of page i from the key of its database without moving the file position, or
+// returns an empty string for an unknown key.
+func (f *File) peekImageType(i int) string {
+	resume, _ := f.handle.Seek(0, 1)
+	defer f.handle.Seek(resume, 0)
+
+	_, err := f.handle.Seek(f.base+f.totalLengthFirstPart+f.Pages[i].OffsetDataBaseViewer, 0)
+	if err != nil {
+		return ""
+	}
+	key, err := f.readString(16)
+	if err != nil {
+		return ""
+	}
+	return imageTypes[key]
+}
+
+// readImageTable reads the database header and the image table of page i.
+func (f *File) readImageTable(i int) {
+	f.currentPage = i
+	defer func() {
+		f.currentPage = -1
+		f.currentTile = -1
+	}()
+
+	// A field that cannot be read ends the page.
+	readField := func(name string) int {
+		value, err := f.readUint32()
+		if err != nil {
+			log.Panicf("unable to read %v: %v", name, err)
+		}
+		return value
+	}
+
+	// Jump to database.
+	_, _ = f.handle.Seek(f.base+f.totalLengthFirstPart+f.Pages[i].OffsetDataBaseViewer, 0)
+
+	key, _ := f.readString(16)
+	imageType, known := imageTypes[key]
+	if !known {
+		log.Panicf("unknown database type %v at %v", key, f.location())
+	}
+	f.Pages[i].ImageType = imageType
+
+	// Read Length
+	f.Pages[i].ImageWidth = readField("page width")
+
+	// Read Heigth
+	f.Pages[i].ImageHeight = readField("page height")
+
+	// Read BLK
+	f.readCompare([]byte{0x42, 0x4C, 0x4B, 0x5F})
+
+	// Length Database
+	f.Pages[i].LengthDatabase = readField("database length")
+
+	// DATABASES START
+	f.readSectionCode("image table")
+
+	// 0028 	4 	00 00 00 20 	each entrance length: 0X20
+	f.Pages[i].EntranceLength = readField("entry length")
+	// f.readCompare([]byte{0x00, 0x00, 0x00, 0x20})
+
+	// 002C 	4 	00 00 00 04 	each parameter length: 0X04
+	f.Pages[i].ParamLength = readField("parameter length")
+	// f.readCompare([]byte{0x00, 0x00, 0x00, 0x04})
+
+	if f.LogLevel >= LogVerbose {
+		log.Printf("[%v] length: %v", i, f.Pages[i].ImageWidth)
+		log.Printf("[%v] height: %v", i, f.Pages[i].ImageHeight)
+		log.Printf("[%v] lengthDatabase: %v", i, f.Pages[i].LengthDatabase)
+		log.Printf("[%v] entryLength: %v", i, f.Pages[i].EntranceLength)
+		log.Printf("[%v] paramLength: %v", i, f.Pages[i].ParamLength)
+	}
+
+	if f.Pages[i].EntranceLength <= 0 {
+		log.Panicf("invalid entry length %v at %v", f.Pages[i].EntranceLength, f.location())
+	}
+
+	// Read images
+	numImages := f.Pages[i].LengthDatabase / f.Pages[i].EntranceLength
+
+	// The image table has to fit into the database of the page, a larger count comes from a misread length.
+	maxImages := int(max(f.Pages[i].LengthDataBaseViewer-imageTableOffset, 0) / int64(f.Pages[i].EntranceLength))
+	if numImages > maxImages {
+		if !f.Force {
+			log.Panicf("database length %v of page %v gives %v images, but only %v fit into its %v bytes at %v", f.Pages[i].LengthDatabase, i, numImages, maxImages, f.Pages[i].LengthDataBaseViewer, f.location())
+		}
+		f.warnf("Database length %v of page %v gives %v images, but only %v fit into its %v bytes, reading %v.", f.Pages[i].LengthDatabase, i, numImages, maxImages, f.Pages[i].LengthDataBaseViewer, maxImages)
+		numImages = maxImages
+	}
+
+	// A remainder hints at a wrong entry length.
+	remainder := f.Pages[i].LengthDatabase % f.Pages[i].EntranceLength
+	if f.LogLevel >= LogVerbose {
+		log.Printf("[%v] numImages: %v (remainder %v)", i, numImages, remainder)
+	}
+	if remainder != 0 {
+		f.warnf("Database length %v of page %v is not a multiple of the entry length %v (remainder %v).", f.Pages[i].LengthDatabase, i, f.Pages[i].EntranceLength, remainder)
+	}
+	if f.Pages[i].ParamLength != 4 {
+		// Kept as is, so the data of unsupported variants can be used to implement their layout.
+		raw, err := f.readBytes(numImages * f.Pages[i].EntranceLength)
+		if err != nil {
+			log.Panicf("unable to read the image table at %v: %v", f.location(), err)
+		}
+		f.warnf("Parameter length %v of page %v is not implemented, skipping its %v images.", f.Pages[i].ParamLength, i, numImages)
+		f.Pages[i].Images = nil
+		f.Pages[i].entryTable = raw
+		return
+	}
+	f.Pages[i].Images = make([]ImageInfo, numImages)
+
+	for j := int(0); j < numImages; j++ {
+		f.currentTile = j
+
+		// 0030 	4 	00 00 00 xx 	Grid position Width (hex): as horizontal line, left to right.
+		f.Pages[i].Images[j].GridPosW = readField("grid position")
+		// 0034 	4 	00 00 00 xx 	Grid position Height (hex): next position after each horizontal line.
+		f.Pages[i].Images[j].GridPosH = readField("grid position")
+		// 0038 	4 	00 00 00 0x 	Layer level: layer 0 (max zoom) appear first.
+		f.Pages[i].Images[j].Layer = readField("layer")
+		// 003C 	4 	00 00 xx xx 	Length of the image (hex)
+		f.Pages[i].Images[j].FileLength = readField("image length")
+		// 0040 	4 	00 00 00 xx 	Length padding of the image (hex)
+		f.Pages[i].Images[j].FileLengthPadding = readField("image padding")
+		// 0044 	4 	00 00 00 00 	Not used? Kept for analysis.
+		f.Pages[i].Images[j].Reserved = readField("field 0044")
+		// 0048 	4 	00 00 0x xx 	Width image (hex)
+		f.Pages[i].Images[j].Width = readField("image width")
+		// 004C 	4 	00 00 0x xx 	Height image (hex)
+		f.Pages[i].Images[j].Height = readField("image height")
+
+		if f.LogLevel >= LogTrace {
+			log.Printf("   > %#v", f.Pages[i].Images[j])
+		}
+	}
+
+	f.checkPageSize(i)
+}
+
+// checkPageSize compares the page size of page i with the extent of its layer 0 tiles, which matches it exactly in
+// all known files. If only the transposed size matches, width and height were read swapped. This is reported and, with
+// -auto-fix, the two are swapped back.
+func (f *File) checkPageSize(i int) {
+	width, height := f.Pages[i].ImageWidth, f.Pages[i].ImageHeight
+	extentW, extentH := 0, 0
+	for _, img := range f.Pages[i].Images {
+		if img.Layer != 0 {
+			continue
+		}
+		extentW = max(extentW, img.GridPosW*tilePitch+img.Width)
+		extentH = max(extentH, img.GridPosH*tilePitch+img.Height)
+	}
+	if width == extentW && height == extentH || width != extentH || height != extentW {
+		return
+	}
+
+	f.warnf("Page size %vx%v of page %v is the transpose of the %vx%v covered by its tiles, width and height look swapped.", width, height, i, extentW, extentH)
+	if f.AutoFix {
+		f.Pages[i].ImageWidth, f.Pages[i].ImageHeight = height, width
+		f.logf("   .. Swapped to [%vx%v]", height, width)
+	}
+}
+
+// Known codes of the BLK_ sections of a page database, 00 00 00 01 for the image table and 00 00 00 02 for the images.
+var sectionCodes = map[int]string{
+	1: "image table",
+	2: "image",
+}
+
+// readSectionCode reads the code of a BLK_ section and the 4 bytes after it, which are 0 in all known files. Unknown
+// values are logged with their bytes and parsed anyway, like the known section.
+func (f *File) readSectionCode(section string) {
+	at := f.location()
+	raw, err := f.readBytes(8)
+	if err != nil {
+		log.Panicf("unable to read the code of the %v section at %v: %v", section, at, err)
+	}
+
+	code := int(binary.BigEndian.Uint32(raw[:4]))
+	known, exists := sectionCodes[code]
+	switch {
+	case !exists:
+		f.warnf("Unknown section code % X at %v for the %v section, parsing anyway.", raw, at, section)
+	case known != section:
+		f.warnf("Section code % X at %v belongs to the %v section, expected the %v section, parsing anyway.", raw, at, known, section)
+	case binary.BigEndian.Uint32(raw[4:]) != 0:
+		f.warnf("Unexpected bytes % X after the section code at %v, parsing anyway.", raw[4:], at)
+	}
+}
+
+func (f *File) readCompare(b []byte) {
+	at := f.location()
+	pos, _ := f.handle.Seek(0, 1)
+	raw, _ := f.readBytes(len(b))
+	if bytes.Compare(raw, b) != 0 {
+		if f.HexDump {
+			f.logHexDump(pos)
+			_, _ = f.handle.Seek(pos+int64(len(b)), 0)
+		}
+		if f.Force {
+			f.warnf("Compare failed at %v: %v <> %v, parsing anyway.", at, raw, b)
+			return
+		}
+		log.Panicf("compare failed at %v: %v <> %v", at, raw, b)
+	}
+}
+
+// Number of bytes shown before and after an offset by logHexDump.
+const hexDumpRadius = 32
+
+// logHexDump logs the bytes around an offset like a hex editor, e.g.
+// "00002890  47 56 4d 50 00 00 00 02  00 00 00 20 00 00 05 93  |GVMP....... ....|". The line holding the offset is
+// marked with ">".
+func (f *File) logHexDump(offset int64) {
+	start := max(0, offset-hexDumpRadius)
+	start -= start % 16
+	_, _ = f.handle.Seek(start, 0)
+	raw := make([]byte, offset+hexDumpRadius-start)
+	n, _ := io.ReadFull(f.handle, raw)
+	raw = raw[:n]
+
+	for line := 0; line < len(raw); line += 16 {
+		chunk := raw[line:min(line+16, len(raw))]
+
+		var hexPart, asciiPart strings.Builder
+		for k := 0; k < 16; k++ {
+			if k == 8 {
+				hexPart.WriteString(" ")
+			}
+			if k >= len(chunk) {
+				hexPart.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hexPart, "%02x ", chunk[k])
+			if chunk[k] >= 0x20 && chunk[k] < 0x7F {
+				asciiPart.WriteByte(chunk[k])
+			} else {
+				asciiPart.WriteByte('.')
+			}
+		}
+
+		lineOffset := start + int64(line)
+		marker := " "
+		if offset >= lineOffset && offset < lineOffset+16 {
+			marker = ">"
+		}
+		log.Printf("  %v %08X  %v |%v|", marker, lineOffset, hexPart.String(), asciiPart.String())
+	}
+}
+
+// location describes the current position of the parser, e.g. "offset 0x4A2F10 (page 150, tile 12)".
+func (f *File) location() string {
+	pos, _ := f.handle.Seek(0, 1)
+	if f.currentPage == -1 {
+		return fmt.Sprintf("offset 0x%X", pos)
+	}
+	if f.currentTile == -1 {
+		return fmt.Sprintf("offset 0x%X (page %v)", pos, f.currentPage)
+	}
+	return fmt.Sprintf("offset 0x%X (page %v, tile %v)", pos, f.currentPage, f.currentTile)
+}
+
+func (f *File) readFileNames() error {
+	for i := int(0); i < f.totalDataEntries; i++ {
+
+		_, err := f.handle.Seek(f.base+f.totalLengthFirstPart+f.Pages[i].OffsetFileName, 0)
+		if err != nil {
+			return fmt.Errorf("unable to seek: %v", err)
+		}
+
+		nextName, err := f.readString(int(f.Pages[i].LengthFileName))
+		if err != nil {
+			return fmt.Errorf("unable to read filename %v : %v", i, err)
+		}
+		f.Pages[i].FileName, _ = strings.CutSuffix(nextName, ".gvd")
+
+		if f.LogLevel >= LogVerbose {
+			log.Printf(" > %v", nextName)
+		}
+	}
+
+	f.logf(" >> File names done.")
+
+	return nil
+}
+
+// ErrInvalidHeader is the cause of every HeaderError, for errors.Is.
+var ErrInvalidHeader = errors.New("not a PlayView file")
+
+// HeaderError is returned when a file does not start with the TGDT0100 header.
+type HeaderError struct {
+	Header string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("%v: header was %q", ErrInvalidHeader, e.Header)
+}
+
+func (e *HeaderError) Unwrap() error {
+	return ErrInvalidHeader
+}
+
+func (f *File) readHeader() error {
+
+	// 0000 8 "TGDT0100"
+	expectedHeader := "TGDT0100"
+
+	f.logf("Checking header %s", expectedHeader)
+	TGDHeader, err := f.readString(8)
+	if err != nil {
+		return err
+	}
+
+	if strings.Compare(TGDHeader, expectedHeader) != 0 {
+		if !f.Force {
+			return &HeaderError{Header: TGDHeader}
+		}
+		f.warnf("Header mismatch: %q <> %q, parsing anyway.", TGDHeader, expectedHeader)
+	} else {
+		f.logf("...done")
+	}
+
+	// 0008 4 Total data entry (next 0x10) in hex
+	f.totalDataEntries, err = f.readUintN(headerFieldWidth)
+	if err != nil {
+		return err
+	}
+	f.logf("Number of Pages: %v", f.totalDataEntries)
+
+	// 000C 4 Total Length first part/start second part (first image id.gvd)
+	f.totalLengthFirstPart, err = f.readOffsetN(headerFieldWidth)
+	if err != nil {
+		return err
+	}
+	if f.LogLevel >= LogVerbose {
+		log.Printf("totalLengthFirstPart: %v", f.totalLengthFirstPart)
+	}
+
+	// The second part cannot start inside the page table.
+	headerLength := int64(8+2*headerFieldWidth) + int64(f.totalDataEntries)*int64(4*headerFieldWidth)
+	if f.totalLengthFirstPart < headerLength {
+		return fmt.Errorf("invalid length of first part: %v (header with %v pages needs at least %v)", f.totalLengthFirstPart, f.totalDataEntries, headerLength)
+	}
+
+	f.Pages = make([]PageInfo, f.totalDataEntries)
+
+	// 0020 xx Repeat for pages
+	for i := int(0); i < f.totalDataEntries; i++ {
+
+		// 0010 4 Offset file name.gvd (without header TGDT0100)
+		f.Pages[i].OffsetFileName, err = f.readOffsetN(headerFieldWidth)
+		if err != nil {
+			return err
+		}
+
+		// 0014 4 Length file name.gvd (00 is not counted)
+		f.Pages[i].LengthFileName, err = f.readUintN(headerFieldWidth)
+		if err != nil {
+			return err
+		}
+
+		// 0018 4 Offset Data Base Viewer
+		f.Pages[i].OffsetDataBaseViewer, err = f.readOffsetN(headerFieldWidth)
+		if err != nil {
+			return err
+		}
+
+		// 001C 4 Length Data base Viewer file
+		f.Pages[i].LengthDataBaseViewer, err = f.readOffsetN(headerFieldWidth)
+		if err != nil {
+			return err
+		}
+
+		if f.LogLevel >= LogVerbose {
+			log.Printf("[Page %v] offsetFileName: %v", i, f.Pages[i].OffsetFileName)
+			log.Printf("[Page %v] lengthFileName: %v", i, f.Pages[i].LengthFileName)
+			log.Printf("[Page %v] offsetDataBaseViewer: %v", i, f.Pages[i].OffsetDataBaseViewer)
+			log.Printf("[Page %v] lengthDataBaseViewer: %v", i, f.Pages[i].LengthDataBaseViewer)
+		}
+	}
+
+	// 0XXX xx Filled with 00 until the first image ID.gvd start
+
+	f.logf(" >> Header done.")
+
+	return nil
+}
+
+func (f *File) readBytes(len int) ([]byte, error) {
+	str := make([]byte, len)
+	err := f.readFull(str)
+	if err != nil {
+		return []byte(""), err
+	}
+	return str, nil
+}
+
+// readScratch reads len bytes into the scratch buffer of the file. The data is only valid until the next call.
+func (f *File) readScratch(len int) ([]byte, error) {
+	if cap(f.scratch) < len {
+		f.scratch = make([]byte, len)
+	}
+	data := f.scratch[:len]
+	err := f.readFull(data)
+	if err != nil {
+		return []byte(""), err
+	}
+	return data, nil
+}
+
+// Wait before the first retry of a failed read, each further retry waits once more as long.
+const readRetryDelay = 200 * time.Millisecond
+
+// readFull fills data from the current position. A failed read is retried from the same position up to ReadRetries
+// times, for transient errors of network shares. The end of the file is never retried.
+func (f *File) readFull(data []byte) error {
+	if f.ReadRetries <= 0 {
+		_, err := io.ReadFull(f.handle, data)
+		return err
+	}
+
+	start, err := f.handle.Seek(0, 1)
+	if err != nil {
+		return fmt.Errorf("unable to seek: %v", err)
+	}
+	for attempt := 1; ; attempt++ {
+		_, err = io.ReadFull(f.handle, data)
+		if err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || attempt > f.ReadRetries {
+			return err
+		}
+		f.warnf("Read of %v bytes at 0x%X failed: %v, retrying (%v of %v).", len(data), start, err, attempt, f.ReadRetries)
+		time.Sleep(time.Duration(attempt) * readRetryDelay)
+		_, seekErr := f.handle.Seek(start, 0)
+		if seekErr != nil {
+			return err
+		}
+	}
+}
+
+func (f *File) readString(len int) (string, error) {
+	raw, err := f.readBytes(len)
+	return string(raw), err
+	// Simulate a null terminated string.
+	// return string(raw[:clen(raw)]), err
+}
+
+func (f *File) readUint32() (int, error) {
+	return f.readUintN(4)
+}
+
+// readUintN reads a big endian unsigned integer that is n (1 to 7) bytes wide.
+//
+// Values above math.MaxInt32 are rejected, so counts and lengths keep their value in an int on 32-bit platforms.
+func (f *File) readUintN(n int) (int, error) {
+	at := f.location()
+	value, err := f.readOffsetN(n)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxInt32 {
+		return 0, fmt.Errorf("value %v at %v is out of range", value, at)
+	}
+	return int(value), nil
+}
+
+// readOffsetN reads a big endian unsigned integer that is n (1 to 7) bytes wide as int64.
+//
+// Offsets and lengths within the file are read through this, so they do not overflow int on 32-bit platforms.
+func (f *File) readOffsetN(n int) (int64, error) {
+	if n < 1 || n > 7 {
+		return 0, fmt.Errorf("unsupported field width: %v", n)
+	}
+	at := f.location()
+	raw, err := f.readBytes(n)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read %v bytes at %v: %v", n, at, err)
+	}
+	padded := make([]byte, 8)
+	copy(padded[8-n:], raw)
+	return int64(binary.BigEndian.Uint64(padded)), nil
+}
+
+func (f *File) readUint4() (int, int, error) {
+	raw, err := f.readBytes(1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rawByte := int(raw[0])
+	upper := rawByte >> 4
+	lower := rawByte & 0x0F
+
+	return int(upper), int(lower), nil
+}
+
+func clen(n []byte) int {
+	for i := 0; i < len(n); i++ {
+		if n[i] == 0 {
+			return i
+		}
+	}
+	return len(n)
+}
@@ -0,0 +1,224 @@
+package playview
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnknownSectionCode(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	// The code of the image table section follows its marker and length.
+	marker := bytes.Index(data, blockMarker)
+	if marker == -1 || data[marker+11] != 0x01 {
+		t.Fatalf("no image table section in the test book")
+	}
+	data[marker+11] = 0x07
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		t.Fatalf("unable to write book: %v", err)
+	}
+
+	e := New(Options{MergeImages: true, ValidateOnly: true})
+	err = e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if e.Stats.Warnings != 1 || e.Stats.DecodedTiles != 4 || len(e.Stats.CrashedPages) != 0 {
+		t.Errorf("got %v warnings, %v decoded tiles and crashed pages %v, expected 1 warning and 4 tiles", e.Stats.Warnings, e.Stats.DecodedTiles, e.Stats.CrashedPages)
+	}
+}
+
+func TestMaxTileBytes(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, test := range []struct {
+		maxTileBytes int
+		decoded      int
+	}{
+		{maxTileBytes: 0, decoded: 4},
+		{maxTileBytes: 1 << 20, decoded: 4},
+		{maxTileBytes: 16, decoded: 0},
+	} {
+		e := New(Options{MergeImages: true, ValidateOnly: true, MaxTileBytes: test.maxTileBytes})
+		err := e.ExtractFile(filePath)
+		if err != nil {
+			t.Fatalf("unable to extract: %v", err)
+		}
+		if e.Stats.DecodedTiles != test.decoded || e.Stats.FailedTiles != 4-test.decoded {
+			t.Errorf("max %v bytes: %v decoded and %v failed tiles, expected %v decoded", test.maxTileBytes, e.Stats.DecodedTiles, e.Stats.FailedTiles, test.decoded)
+		}
+	}
+}
+
+func TestSwappedPageSize(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	page := PageInfo{ImageWidth: 300, ImageHeight: 600, Images: []ImageInfo{
+		{GridPosW: 1, GridPosH: 0, Width: 256, Height: 256},
+		{GridPosW: 2, GridPosH: 1, Width: 88, Height: 44},
+		{GridPosW: 0, GridPosH: 0, Layer: 1, Width: 300, Height: 150},
+	}}
+
+	for _, autoFix := range []bool{false, true} {
+		e := New(Options{AutoFix: autoFix})
+		f := &File{Extractor: e, Pages: []PageInfo{page}}
+		f.checkPageSize(0)
+		if e.Stats.Warnings != 1 {
+			t.Errorf("auto fix %v: got %v warnings, expected 1", autoFix, e.Stats.Warnings)
+		}
+		width, height := f.Pages[0].ImageWidth, f.Pages[0].ImageHeight
+		if autoFix && (width != 600 || height != 300) || !autoFix && (width != 300 || height != 600) {
+			t.Errorf("auto fix %v: page size is %vx%v", autoFix, width, height)
+		}
+	}
+
+	e := New(Options{AutoFix: true})
+	page.ImageWidth, page.ImageHeight = 600, 300
+	f := &File{Extractor: e, Pages: []PageInfo{page}}
+	f.checkPageSize(0)
+	if e.Stats.Warnings != 0 || f.Pages[0].ImageWidth != 600 {
+		t.Errorf("matching page size got %v warnings and was changed to %vx%v", e.Stats.Warnings, f.Pages[0].ImageWidth, f.Pages[0].ImageHeight)
+	}
+}
+
+// flakyInput fails the first failures reads.
+type flakyInput struct {
+	*bytes.Reader
+	failures int
+}
+
+func (r *flakyInput) Read(p []byte) (int, error) {
+	if r.failures > 0 {
+		r.failures--
+		// Part of the data arrives before the error, the retry has to start over.
+		n, _ := r.Reader.Read(p[:len(p)/2])
+		return n, errors.New("connection reset")
+	}
+	return r.Reader.Read(p)
+}
+
+func (r *flakyInput) Close() error {
+	return nil
+}
+
+func TestReadRetries(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, test := range []struct {
+		retries  int
+		failures int
+		fails    bool
+	}{
+		{retries: 0, failures: 1, fails: true},
+		{retries: 2, failures: 2, fails: false},
+		{retries: 2, failures: 3, fails: true},
+	} {
+		e := New(Options{ReadRetries: test.retries})
+		f := &File{Extractor: e, handle: &flakyInput{Reader: bytes.NewReader([]byte("TGDT0100")), failures: test.failures}}
+		raw, err := f.readBytes(8)
+		if test.fails != (err != nil) {
+			t.Errorf("%v retries, %v failures: got error %v", test.retries, test.failures, err)
+		}
+		if !test.fails && string(raw) != "TGDT0100" {
+			t.Errorf("%v retries, %v failures: read %q", test.retries, test.failures, raw)
+		}
+		if want := min(test.retries, test.failures); e.Stats.Warnings != want {
+			t.Errorf("%v retries, %v failures: got %v warnings, expected %v", test.retries, test.failures, e.Stats.Warnings, want)
+		}
+	}
+
+	// The end of the file is not retried.
+	e := New(Options{ReadRetries: 2})
+	f := &File{Extractor: e, handle: &flakyInput{Reader: bytes.NewReader([]byte("TGDT"))}}
+	_, err := f.readBytes(8)
+	if err == nil || e.Stats.Warnings != 0 {
+		t.Errorf("short file: got error %v and %v warnings", err, e.Stats.Warnings)
+	}
+}
+
+func TestUnsupportedParamLength(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	outDir := t.TempDir()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	e := New(Options{OutDir: outDir, MergeImages: true})
+	f, err := e.Open(filePath)
+	if err != nil {
+		t.Fatalf("unable to open book: %v", err)
+	}
+	defer f.Close()
+
+	// Parameter length 8 instead of 4, right in front of the image table.
+	book, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	table := f.totalLengthFirstPart + f.Pages[0].OffsetDataBaseViewer + imageTableOffset
+	book[table-1] = 8
+	err = os.WriteFile(filePath, book, 0644)
+	if err != nil {
+		t.Fatalf("unable to write book: %v", err)
+	}
+
+	err = f.exportPageRecovered(0)
+	if err != nil {
+		t.Fatalf("unable to export page: %v", err)
+	}
+	if e.Stats.FailedPages != 1 || len(e.Stats.CrashedPages) != 0 || e.Stats.Warnings != 1 {
+		t.Errorf("got %v failed pages, crashed pages %v and %v warnings, expected 1 failed page and 1 warning", e.Stats.FailedPages, e.Stats.CrashedPages, e.Stats.Warnings)
+	}
+
+	dumped, err := os.ReadFile(filepath.Join(outDir, f.Pages[0].FileName+"_entries.bin"))
+	if err != nil {
+		t.Fatalf("unable to read image table: %v", err)
+	}
+	if want := book[table : table+4*0x20]; !bytes.Equal(dumped, want) {
+		t.Errorf("image table is %x, expected %x", dumped, want)
+	}
+}
+
+func TestReadStructureDamagedPage(t *testing.T) {
+	filePath := writeTestBook(t, 2, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// Break the database key of the second page.
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	key := bytes.LastIndex(data, []byte("GVEW0100JPEG0100"))
+	copy(data[key:], "broken")
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		t.Fatalf("unable to write book: %v", err)
+	}
+
+	pages, err := New(Options{}).ReadStructure(filePath)
+	if err != nil {
+		t.Fatalf("unable to read structure: %v", err)
+	}
+	if pages[0].ReadError != nil || len(pages[0].Images) != 4 {
+		t.Errorf("got error %v and %v images for the first page, expected 4 images", pages[0].ReadError, len(pages[0].Images))
+	}
+	if pages[1].ReadError == nil || pages[1].Images != nil {
+		t.Errorf("damaged page was read without error")
+	}
+}
@@ -0,0 +1,198 @@
+package playview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Size of the blocks fetched from a remote file, the parser mostly reads small fields close to each other.
+const remoteBlockSize = 256 * 1024
+
+// Blocks kept in memory per remote file (16 MiB), the least recently used block is dropped first.
+const remoteCachedBlocks = 64
+
+// Time a range request may take until its block is read completely.
+const remoteBlockTimeout = 2 * time.Minute
+
+// remoteClient gives up on servers that do not connect or answer, instead of waiting forever like the default client.
+// The body of a response has no deadline of its own, the requests of blocks are limited by remoteBlockTimeout.
+var remoteClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// remoteFile reads a gvd.dat over HTTP with range requests. Recently fetched blocks are kept in memory, so the byte
+// ranges the parser reads close to each other are downloaded once.
+type remoteFile struct {
+	url    string
+	size   int64
+	blocks map[int64][]byte
+
+	// Indices of the cached blocks from the least to the most recently used.
+	recent []int64
+
+	// Downloaded bytes, for the log.
+	fetched int64
+	logf    func(format string, v ...any)
+}
+
+// isRemotePath checks whether a path is an http or https URL.
+func isRemotePath(filePath string) bool {
+	return strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://")
+}
+
+// openRemote opens a gvd.dat given by its URL. The server has to support range requests.
+func (e *Extractor) openRemote(fileURL string) (input, error) {
+	remote := &remoteFile{url: fileURL, blocks: map[int64][]byte{}, logf: e.logf}
+
+	// The size comes with the range of the first byte.
+	response, cancel, err := remote.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	_ = response.Body.Close()
+	cancel()
+
+	_, total, found := strings.Cut(response.Header.Get("Content-Range"), "/")
+	remote.size, err = strconv.ParseInt(total, 10, 64)
+	if !found || err != nil {
+		return nil, fmt.Errorf("unable to get the size of %v: missing content range", fileURL)
+	}
+
+	e.logf("Streaming %v (%v bytes)", fileURL, remote.size)
+
+	return archiveEntry{ReadSeeker: io.NewSectionReader(remote, 0, remote.size), Closer: remote}, nil
+}
+
+// get requests the bytes from start to end (inclusive). The request is cancelled when the returned function is
+// called, which has to happen once the body is read.
+func (r *remoteFile) get(start int64, end int64) (*http.Response, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteBlockTimeout)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("unable to request %v: %v", r.url, err)
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%v-%v", start, end))
+
+	response, err := remoteClient.Do(request)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("unable to request %v: %v", r.url, err)
+	}
+	if response.StatusCode != http.StatusPartialContent {
+		_ = response.Body.Close()
+		cancel()
+		return nil, nil, fmt.Errorf("unable to request %v: server answered %v instead of a range", r.url, response.Status)
+	}
+	return response, cancel, nil
+}
+
+// downloadRemote requests a remote file as a whole, for reading it once from start to end.
+func downloadRemote(fileURL string) (io.ReadCloser, error) {
+	response, err := remoteClient.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request %v: %v", fileURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("unable to request %v: server answered %v", fileURL, response.Status)
+	}
+	return response.Body, nil
+}
+
+// block returns the cached block at index n, fetching it if needed.
+func (r *remoteFile) block(n int64) ([]byte, error) {
+	if data, cached := r.blocks[n]; cached {
+		r.touch(n)
+		return data, nil
+	}
+
+	start := n * remoteBlockSize
+	end := min(start+remoteBlockSize, r.size) - 1
+	response, cancel, err := r.get(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer response.Body.Close()
+
+	data := make([]byte, end-start+1)
+	_, err = io.ReadFull(response.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %v", r.url, err)
+	}
+	r.fetched += int64(len(data))
+
+	// Drop the least recently used block to make room.
+	if len(r.recent) >= remoteCachedBlocks {
+		delete(r.blocks, r.recent[0])
+		r.recent = r.recent[1:]
+	}
+	r.blocks[n] = data
+	r.recent = append(r.recent, n)
+	return data, nil
+}
+
+// touch marks the cached block at index n as the most recently used.
+func (r *remoteFile) touch(n int64) {
+	at := slices.Index(r.recent, n)
+	if at == len(r.recent)-1 {
+		return
+	}
+	r.recent = append(slices.Delete(r.recent, at, at+1), n)
+}
+
+// ReadAt implements io.ReaderAt on top of the cached blocks.
+func (r *remoteFile) ReadAt(p []byte, offset int64) (int, error) {
+	read := 0
+	for read < len(p) {
+		pos := offset + int64(read)
+		if pos >= r.size {
+			return read, io.EOF
+		}
+		data, err := r.block(pos / remoteBlockSize)
+		if err != nil {
+			return read, err
+		}
+		read += copy(p[read:], data[pos%remoteBlockSize:])
+	}
+	return read, nil
+}
+
+func (r *remoteFile) Close() error {
+	r.logf(" >> Fetched %v of %v bytes from %v", r.fetched, r.size, r.url)
+	r.blocks = nil
+	r.recent = nil
+	return nil
+}
+
+// remoteBookName names a book by the URL of its gvd.dat, like BookName does for paths.
+func remoteBookName(fileURL string) string {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return "gvd"
+	}
+	base := path.Base(parsed.Path)
+	if !strings.EqualFold(base, "gvd.dat") {
+		return strings.TrimSuffix(base, path.Ext(base))
+	}
+	if dir := path.Base(path.Dir(parsed.Path)); dir != "/" && dir != "." {
+		return dir
+	}
+	return parsed.Hostname()
+}
@@ -0,0 +1,89 @@
+package playview
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRemoteFileCache(t *testing.T) {
+	content := make([]byte, (remoteCachedBlocks+1)*remoteBlockSize)
+	for n := range content {
+		content[n] = byte(n / remoteBlockSize)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "gvd.dat", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	e := New(Options{})
+	remote, err := e.openRemote(server.URL + "/gvd.dat")
+	if err != nil {
+		t.Fatalf("unable to open: %v", err)
+	}
+	defer remote.Close()
+	file := remote.(archiveEntry).Closer.(*remoteFile)
+
+	read, err := io.ReadAll(remote)
+	if err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	if !bytes.Equal(read, content) {
+		t.Fatalf("read content differs")
+	}
+	if len(file.blocks) != remoteCachedBlocks {
+		t.Errorf("got %v cached blocks, expected %v", len(file.blocks), remoteCachedBlocks)
+	}
+	if _, cached := file.blocks[0]; cached {
+		t.Errorf("least recently used block is still cached")
+	}
+
+	// A cached block is read again without fetching it.
+	fetched := file.fetched
+	_, err = file.ReadAt(make([]byte, 16), remoteBlockSize)
+	if err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	if file.fetched != fetched {
+		t.Errorf("cached block was fetched again")
+	}
+	if file.recent[len(file.recent)-1] != 1 {
+		t.Errorf("block read last is not the most recently used")
+	}
+}
+
+func TestRemoteSourceHash(t *testing.T) {
+	content := []byte("TGDT0100 remote book")
+	var ranges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranges++
+		}
+		http.ServeContent(w, r, "gvd.dat", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	e := New(Options{ValidateOnly: true})
+	err := e.hashSource(server.URL + "/gvd.dat")
+	if err != nil {
+		t.Fatalf("unable to hash: %v", err)
+	}
+	expected := sha256.Sum256(content)
+	if e.sourceHash != hex.EncodeToString(expected[:]) {
+		t.Errorf("got hash %v, expected %x", e.sourceHash, expected)
+	}
+	if ranges != 0 {
+		t.Errorf("hashed with %v range requests, expected a single download", ranges)
+	}
+}
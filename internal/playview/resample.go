@@ -0,0 +1,139 @@
+package playview
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// resampleKernel is a separable filter for resizing images (used by -resample).
+type resampleKernel struct {
+	// Radius of the kernel in source pixels when upscaling.
+	support float64
+	weight  func(x float64) float64
+}
+
+// Resamplers besides nearest, which is done by ScaleImage. The tiles themselves are always drawn 1:1, these only
+// apply where a merged image is resized (-fit, -align-layers, -zoom-anim, -fill-from-layer, -contact-sheet).
+var resampleKernels = map[string]resampleKernel{
+	"bilinear": {support: 1, weight: func(x float64) float64 {
+		return max(0, 1-x)
+	}},
+	"catmullrom": {support: 2, weight: func(x float64) float64 {
+		if x < 1 {
+			return (1.5*x-2.5)*x*x + 1
+		}
+		if x < 2 {
+			return ((-0.5*x+2.5)*x-4)*x + 2
+		}
+		return 0
+	}},
+}
+
+// IsResampler checks whether name is a known resampler.
+func IsResampler(name string) bool {
+	_, exists := resampleKernels[name]
+	return exists || name == "" || name == "nearest"
+}
+
+// MegapixelSize returns the largest size with the aspect ratio of width x height that has at most megapixels million
+// pixels, and whether it is smaller than width x height.
+func MegapixelSize(width int, height int, megapixels float64) (int, int, bool) {
+	budget := megapixels * 1e6
+	if width <= 0 || height <= 0 || float64(width)*float64(height) <= budget {
+		return width, height, false
+	}
+	scale := math.Sqrt(budget / (float64(width) * float64(height)))
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale)), true
+}
+
+// ResampleImage resizes an image to the given size with the named resampler: nearest (or empty), bilinear or
+// catmullrom.
+func ResampleImage(img image.Image, width int, height int, resampler string) *image.RGBA {
+	kernel, exists := resampleKernels[resampler]
+	bounds := img.Bounds()
+	if !exists || bounds.Empty() || (bounds.Dx() == width && bounds.Dy() == height) {
+		return ScaleImage(img, width, height)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	// Resize the rows first, then the columns.
+	weightsX := axisWeights(width, srcWidth, kernel)
+	weightsY := axisWeights(height, srcHeight, kernel)
+
+	rows := make([]float64, srcHeight*width*4)
+	for y := 0; y < srcHeight; y++ {
+		for x, weights := range weightsX {
+			out := rows[(y*width+x)*4:]
+			for _, w := range weights {
+				in := src.Pix[y*src.Stride+w.index*4:]
+				for c := 0; c < 4; c++ {
+					out[c] += w.weight * float64(in[c])
+				}
+			}
+		}
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, weights := range weightsY {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for _, w := range weights {
+				in := rows[(w.index*width+x)*4:]
+				for c := 0; c < 4; c++ {
+					sum[c] += w.weight * in[c]
+				}
+			}
+
+			// The pixels are premultiplied, so no channel may exceed the alpha after an overshoot.
+			alpha := clampChannel(sum[3], 255)
+			out := scaled.Pix[y*scaled.Stride+x*4:]
+			for c := 0; c < 3; c++ {
+				out[c] = clampChannel(sum[c], float64(alpha))
+			}
+			out[3] = alpha
+		}
+	}
+
+	return scaled
+}
+
+// sampleWeight is the share of a source pixel in a resized pixel.
+type sampleWeight struct {
+	index  int
+	weight float64
+}
+
+// axisWeights returns for every pixel along an axis of dstSize pixels the weighted source pixels it is made of. When
+// downscaling, the kernel is stretched to cover all source pixels.
+func axisWeights(dstSize int, srcSize int, kernel resampleKernel) [][]sampleWeight {
+	scale := float64(srcSize) / float64(dstSize)
+	stretch := max(scale, 1)
+	support := kernel.support * stretch
+
+	weights := make([][]sampleWeight, dstSize)
+	for d := range weights {
+		center := (float64(d)+0.5)*scale - 0.5
+		sum := 0.0
+		for s := int(math.Floor(center - support)); s <= int(math.Ceil(center+support)); s++ {
+			weight := kernel.weight(math.Abs(float64(s)-center) / stretch)
+			if weight == 0 {
+				continue
+			}
+			weights[d] = append(weights[d], sampleWeight{index: min(max(s, 0), srcSize-1), weight: weight})
+			sum += weight
+		}
+		for n := range weights[d] {
+			weights[d][n].weight /= sum
+		}
+	}
+	return weights
+}
+
+// clampChannel rounds a channel value and limits it to 0..limit.
+func clampChannel(value float64, limit float64) uint8 {
+	return uint8(min(max(math.Round(value), 0), limit))
+}
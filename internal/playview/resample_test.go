@@ -0,0 +1,100 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestResampleImageUniform(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 7, 5))
+	fill := color.RGBA{R: 0x40, G: 0x80, B: 0xC0, A: 0xFF}
+	draw.Draw(src, src.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+
+	for _, resampler := range []string{"nearest", "bilinear", "catmullrom"} {
+		for _, size := range []image.Point{{X: 3, Y: 2}, {X: 7, Y: 5}, {X: 20, Y: 13}} {
+			scaled := ResampleImage(src, size.X, size.Y, resampler)
+			if scaled.Bounds().Size() != size {
+				t.Fatalf("%v: size %v, expected %v", resampler, scaled.Bounds().Size(), size)
+			}
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					if got := scaled.RGBAAt(x, y); got != fill {
+						t.Fatalf("%v to %v: pixel %v,%v is %v, expected %v", resampler, size, x, y, got, fill)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResampleImageSameSize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(10, 10, 14, 13))
+	for n := range src.Pix {
+		src.Pix[n] = uint8(n * 7)
+	}
+	for n := 3; n < len(src.Pix); n += 4 {
+		src.Pix[n] = 0xFF
+	}
+
+	for _, resampler := range []string{"nearest", "bilinear", "catmullrom"} {
+		scaled := ResampleImage(src, 4, 3, resampler)
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				if got, want := scaled.RGBAAt(x, y), src.RGBAAt(10+x, 10+y); got != want {
+					t.Errorf("%v: pixel %v,%v is %v, expected %v", resampler, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestResampleImageGradient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{A: 0xFF})
+	src.SetRGBA(1, 0, color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+
+	scaled := ResampleImage(src, 8, 1, "bilinear")
+	for x := 1; x < 8; x++ {
+		if scaled.RGBAAt(x, 0).R < scaled.RGBAAt(x-1, 0).R {
+			t.Errorf("pixel %v is darker than pixel %v: %v", x, x-1, scaled.Pix[:32])
+		}
+	}
+	if scaled.RGBAAt(0, 0).R != 0 || scaled.RGBAAt(7, 0).R != 0xFF {
+		t.Errorf("edges are %v and %v, expected black and white", scaled.RGBAAt(0, 0), scaled.RGBAAt(7, 0))
+	}
+}
+
+func TestIsResampler(t *testing.T) {
+	for _, name := range []string{"", "nearest", "bilinear", "catmullrom"} {
+		if !IsResampler(name) {
+			t.Errorf("IsResampler(%q) = false", name)
+		}
+	}
+	if IsResampler("lanczos") {
+		t.Errorf("IsResampler(\"lanczos\") = true")
+	}
+}
+
+func TestMegapixelSize(t *testing.T) {
+	for _, test := range []struct {
+		width, height int
+		megapixels    float64
+		want          image.Point
+		scaled        bool
+	}{
+		{width: 1000, height: 1000, megapixels: 1, want: image.Pt(1000, 1000)},
+		{width: 4000, height: 2000, megapixels: 2, want: image.Pt(2000, 1000), scaled: true},
+		{width: 6000, height: 4000, megapixels: 1.5, want: image.Pt(1500, 1000), scaled: true},
+		{width: 3000, height: 1000, megapixels: 0.12, want: image.Pt(600, 200), scaled: true},
+	} {
+		width, height, scaled := MegapixelSize(test.width, test.height, test.megapixels)
+		if image.Pt(width, height) != test.want || scaled != test.scaled {
+			t.Errorf("%vx%v for %v megapixels: got %vx%v (scaled %v), expected %v (scaled %v)", test.width, test.height, test.megapixels, width, height, scaled, test.want, test.scaled)
+		}
+		if float64(width*height) > test.megapixels*1e6 {
+			t.Errorf("%vx%v for %v megapixels: %vx%v is over the budget", test.width, test.height, test.megapixels, width, height)
+		}
+	}
+}
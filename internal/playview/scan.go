@@ -0,0 +1,183 @@
+package playview
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"log"
+)
+
+// Longest run from a JPEG start marker to an end marker that is still taken as one image by the scan.
+const maxScanImageLength = 32 << 20
+
+// scanFile carves the images out of a damaged file by their markers instead of trusting the length and padding
+// fields (used by -scan-mode). Every run from a JPEG start marker (FF D8 FF) to an end marker (FF D9) that decodes is
+// exported as scan_<n>_<offset>, the two images following a GVMP header get _A and _B appended. A truncated image
+// ends at the next start marker, an image within another one is taken as its thumbnail. Grid positions are unknown,
+// so nothing is merged.
+func (e *Extractor) scanFile(filePath string) error {
+	e.logf("Scanning %v", filePath)
+
+	handle, err := e.openInput(filePath)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	reader := bufio.NewReaderSize(handle, 1<<20)
+	name := "scan"
+	if e.NumberPages {
+		// Keep the images of several files apart.
+		name = fmt.Sprintf("%v_scan", BookName(filePath))
+	}
+
+	found, dualImages, dualImage := 0, 0, 0
+	export := func(img image.Image, start int64) error {
+		found++
+		e.Stats.DecodedTiles++
+
+		imageName := fmt.Sprintf("%v_%05d_%X", name, found, start)
+		if dualImage > 0 {
+			imageName += []string{"_B", "_A"}[dualImage-1]
+			dualImage--
+		}
+		if e.LogLevel >= LogVerbose {
+			log.Printf("   .. Image [%v] at 0x%X (%vx%v)", imageName, start, img.Bounds().Dx(), img.Bounds().Dy())
+		}
+		if e.OnImage != nil {
+			e.OnImage(name, found, img)
+		}
+		return e.sinkImage(imageName, img)
+	}
+
+	// The data from the first open start marker on, which is at base in the file, and the positions of the open start
+	// markers in it. A second image may start within the first one, as its embedded thumbnail or after a broken start.
+	var current []byte
+	var starts []int
+	var base int64
+
+	// An image that decoded within the first open one. It is exported unless the first one decodes at the next end
+	// marker, then it was its thumbnail.
+	var pending image.Image
+	var pendingStart int64
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		img := pending
+		pending = nil
+		return export(img, pendingStart)
+	}
+
+	// dropFirst gives up the first open image and continues with the second one, if there is any.
+	dropFirst := func() {
+		if len(starts) < 2 {
+			current, starts = nil, nil
+			return
+		}
+		current = current[starts[1]:]
+		base += int64(starts[1])
+		starts = []int{0}
+	}
+
+	var offset int64
+	var window uint32
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read %v: %v", filePath, err)
+		}
+		offset++
+		window = window<<8 | uint32(b)
+
+		if len(starts) == 0 && window == 0x47564D50 { // "GVMP"
+			dualImages++
+			dualImage = 2
+		}
+		if len(starts) > 0 {
+			current = append(current, b)
+		}
+
+		// Every start marker begins a new candidate, so a broken image does not hide the ones following it.
+		if window&0xFFFFFF == 0xFFD8FF {
+			if pending != nil {
+				err := flush()
+				if err != nil {
+					return err
+				}
+				current, starts = nil, nil
+			}
+			if len(starts) == 2 {
+				dropFirst()
+			}
+			if len(starts) == 0 {
+				current = []byte{0xFF, 0xD8, 0xFF}
+				base = offset - 3
+			}
+			starts = append(starts, len(current)-3)
+			continue
+		}
+		if len(starts) == 0 {
+			continue
+		}
+
+		if len(current) > maxScanImageLength {
+			if e.LogLevel >= LogVerbose {
+				log.Printf("   .. No end marker for the image at 0x%X", base)
+			}
+			err := flush()
+			if err != nil {
+				return err
+			}
+			dropFirst()
+			continue
+		}
+		if window&0xFFFF != 0xFFD9 {
+			continue
+		}
+
+		// Each end marker is tried once, for the image started last.
+		last := starts[len(starts)-1]
+		img, err := decodeImage(current[last:], e.JPEGDecoder)
+		if len(starts) == 2 {
+			starts = starts[:1]
+			if err == nil {
+				pending, pendingStart = img, base+int64(last)
+			}
+			continue
+		}
+		if err != nil {
+			if pending != nil {
+				// The first image is broken, the one within it is not a thumbnail.
+				err := flush()
+				if err != nil {
+					return err
+				}
+				current, starts = nil, nil
+			}
+			// Otherwise the end marker may be part of the data, so the image continues up to the next one.
+			continue
+		}
+		pending = nil
+		current, starts = nil, nil
+		err = export(img, base)
+		if err != nil {
+			return err
+		}
+	}
+	err = flush()
+	if err != nil {
+		return err
+	}
+
+	e.logf(" >> Scan found %v images (%v GVMP headers).", found, dualImages)
+	if found > 0 {
+		e.Stats.ProducedPages[name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package playview
+
+import (
+	"image"
+	"io"
+	"log"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestScanFile(t *testing.T) {
+	filePath := writeTestBook(t, 2, 3)
+
+	// Corrupt the page table, the scan does not need it.
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	for n := 8; n < 48; n++ {
+		data[n] = 0xEE
+	}
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		t.Fatalf("unable to write book: %v", err)
+	}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var names []string
+	e := New(Options{ScanMode: true, Sink: func(pageName string, img image.Image) error {
+		if bounds := img.Bounds(); bounds.Dx() != tilePitch || bounds.Dy() != tilePitch {
+			t.Errorf("%v is %vx%v, expected %vx%v", pageName, bounds.Dx(), bounds.Dy(), tilePitch, tilePitch)
+		}
+		names = append(names, pageName)
+		return nil
+	}})
+	err = e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to scan: %v", err)
+	}
+
+	if len(names) != 2*3*3 {
+		t.Errorf("carved %v images, expected %v: %v", len(names), 2*3*3, names)
+	}
+	if len(names) > 0 && names[0][:11] != "scan_00001_" {
+		t.Errorf("first image is named %v, expected scan_00001_<offset>", names[0])
+	}
+}
+
+func TestScanFileMarkers(t *testing.T) {
+	tile := testJPEG(t)
+
+	// A thumbnail in an APP1 segment right after the start marker of the image.
+	thumbnail := []byte{0xFF, 0xD8, 0xFF, 0xE1, byte((len(tile) + 2) >> 8), byte(len(tile) + 2)}
+	thumbnail = append(append(thumbnail, tile...), tile[2:]...)
+
+	tests := []struct {
+		name     string
+		data     [][]byte
+		expected int
+	}{
+		{name: "truncated", data: [][]byte{tile[:len(tile)/2], tile, tile}, expected: 2},
+		{name: "thumbnail", data: [][]byte{thumbnail, tile}, expected: 2},
+	}
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, test := range tests {
+		var data []byte
+		for _, part := range test.data {
+			data = append(data, part...)
+		}
+		filePath := path.Join(t.TempDir(), "gvd.dat")
+		err := os.WriteFile(filePath, data, 0644)
+		if err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+
+		found := 0
+		e := New(Options{ScanMode: true, Sink: func(pageName string, img image.Image) error {
+			found++
+			return nil
+		}})
+		err = e.ExtractFile(filePath)
+		if err != nil {
+			t.Fatalf("%v: unable to scan: %v", test.name, err)
+		}
+		if found != test.expected {
+			t.Errorf("%v: carved %v images, expected %v", test.name, found, test.expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package playview
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// File in OutDir listing the SHA-256 of every extracted input file, in the format of sha256sum (used by -source-hash).
+const sourceHashFileName = "source.sha256"
+
+// hashSource hashes the whole input file before it is parsed and records the hash in OutDir (used by -source-hash).
+// Remote files are downloaded completely for this, in a single request streamed into the hash.
+func (e *Extractor) hashSource(filePath string) error {
+	var handle io.ReadCloser
+	var err error
+	if isRemotePath(filePath) {
+		handle, err = downloadRemote(filePath)
+	} else {
+		handle, err = e.openInput(filePath)
+	}
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, handle)
+	if err != nil {
+		return fmt.Errorf("unable to hash %v: %v", filePath, err)
+	}
+	e.sourceHash = hex.EncodeToString(hash.Sum(nil))
+	if e.Stats.SourceHashes == nil {
+		e.Stats.SourceHashes = map[string]string{}
+	}
+	e.Stats.SourceHashes[filePath] = e.sourceHash
+	e.logf("   .. SHA-256 [%v]", e.sourceHash)
+
+	if e.ValidateOnly {
+		return nil
+	}
+
+	listPath := path.Join(e.OutDir, sourceHashFileName)
+	defer e.acquireWrite()()
+	err = createParentDir(listPath)
+	if err != nil {
+		return err
+	}
+	list, err := os.OpenFile(listPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %v", err)
+	}
+	_, writeErr := fmt.Fprintf(list, "%v  %v\n", e.sourceHash, filePath)
+	closeErr := list.Close()
+	if writeErr != nil {
+		return fmt.Errorf("unable to write source hash: %v", writeErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("unable to close output file: %v", closeErr)
+	}
+	return nil
+}
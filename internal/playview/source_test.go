@@ -0,0 +1,40 @@
+package playview
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSourceHash(t *testing.T) {
+	filePath := writeTestBook(t, 1, 1)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to read book: %v", err)
+	}
+	want := fmt.Sprintf("%x  %v\n", sha256.Sum256(data), filePath)
+
+	outDir := t.TempDir()
+	e := New(Options{MergeImages: true, OutDir: outDir, SourceHash: true})
+	for n := 0; n < 2; n++ {
+		err = e.ExtractFile(filePath)
+		if err != nil {
+			t.Fatalf("unable to extract: %v", err)
+		}
+	}
+
+	list, err := os.ReadFile(path.Join(outDir, sourceHashFileName))
+	if err != nil {
+		t.Fatalf("unable to read hashes: %v", err)
+	}
+	if string(list) != want+want {
+		t.Errorf("hashes are %q, expected %q twice", list, want)
+	}
+}
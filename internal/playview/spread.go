@@ -0,0 +1,82 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// spreadPage is a merged page waiting for the page it is paired with (used by -spread).
+type spreadPage struct {
+	name string
+	img  *image.RGBA
+}
+
+// emitPage passes a merged page to the Sink and the OnPage hook, or pairs it with the next page into a spread.
+func (e *Extractor) emitPage(name string, img image.Image) error {
+	if !e.Spread {
+		return e.sinkPage(name, img)
+	}
+
+	e.spreadPages++
+	if e.SpreadStart == "even" && e.spreadPages == 1 {
+		// The first page (the cover) stands alone.
+		return e.sinkPage(name, img)
+	}
+
+	if e.pendingSpread == nil {
+		// The merged image is reused by the next page, so keep a copy.
+		bounds := img.Bounds()
+		copied := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(copied, copied.Bounds(), img, bounds.Min, draw.Src)
+		e.pendingSpread = &spreadPage{name: name, img: copied}
+		return nil
+	}
+
+	first := e.pendingSpread
+	e.pendingSpread = nil
+	return e.sinkPage(fmt.Sprintf("%v_%v", first.name, name), joinSpread(first.img, img, e.SpreadGutter, e.SpreadRightToLeft))
+}
+
+// flushSpread passes a page still waiting for its pair on its own.
+func (e *Extractor) flushSpread() error {
+	if e.pendingSpread == nil {
+		return nil
+	}
+	page := e.pendingSpread
+	e.pendingSpread = nil
+	return e.sinkPage(page.name, page.img)
+}
+
+// sinkPage passes a merged page to the Sink and the OnPage hook.
+func (e *Extractor) sinkPage(name string, img image.Image) error {
+	err := e.sinkImage(name, img)
+	if err != nil {
+		return err
+	}
+
+	if e.OnPage != nil {
+		e.OnPage(name, img)
+	}
+	return nil
+}
+
+// joinSpread lays out two pages side by side with a transparent gutter between them, the first page on the left or,
+// for right to left books, on the right. Pages of different heights are centered vertically.
+func joinSpread(first image.Image, second image.Image, gutter int, rightToLeft bool) *image.RGBA {
+	left, right := first, second
+	if rightToLeft {
+		left, right = second, first
+	}
+
+	leftBounds, rightBounds := left.Bounds(), right.Bounds()
+	height := max(leftBounds.Dy(), rightBounds.Dy())
+	spread := image.NewRGBA(image.Rect(0, 0, leftBounds.Dx()+gutter+rightBounds.Dx(), height))
+
+	leftAt := image.Pt(0, (height-leftBounds.Dy())/2)
+	draw.Draw(spread, leftBounds.Sub(leftBounds.Min).Add(leftAt), left, leftBounds.Min, draw.Src)
+	rightAt := image.Pt(leftBounds.Dx()+gutter, (height-rightBounds.Dy())/2)
+	draw.Draw(spread, rightBounds.Sub(rightBounds.Min).Add(rightAt), right, rightBounds.Min, draw.Src)
+
+	return spread
+}
@@ -0,0 +1,46 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestJoinSpread(t *testing.T) {
+	red, blue := color.RGBA{R: 0xFF, A: 0xFF}, color.RGBA{B: 0xFF, A: 0xFF}
+	first := image.NewRGBA(image.Rect(0, 0, 4, 6))
+	draw.Draw(first, first.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	second := image.NewRGBA(image.Rect(10, 10, 13, 12))
+	draw.Draw(second, second.Bounds(), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name        string
+		rightToLeft bool
+		left        color.RGBA
+		right       color.RGBA
+		rightY      int
+	}{
+		{name: "left to right", left: red, right: blue, rightY: 2},
+		{name: "right to left", rightToLeft: true, left: blue, right: red, rightY: 0},
+	}
+	for _, test := range tests {
+		spread := joinSpread(first, second, 2, test.rightToLeft)
+		if size := spread.Bounds().Size(); size != image.Pt(4+2+3, 6) {
+			t.Errorf("%v: size %v, expected 9x6", test.name, size)
+		}
+		leftWidth := 4
+		if test.rightToLeft {
+			leftWidth = 3
+		}
+		if got := spread.RGBAAt(0, 3); got != test.left {
+			t.Errorf("%v: left page is %v, expected %v", test.name, got, test.left)
+		}
+		if got := spread.RGBAAt(leftWidth, 3); got.A != 0 {
+			t.Errorf("%v: gutter is %v, expected transparent", test.name, got)
+		}
+		if got := spread.RGBAAt(leftWidth+2, test.rightY); got != test.right {
+			t.Errorf("%v: right page is %v, expected %v", test.name, got, test.right)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"path"
+	"slices"
+)
+
+// Transparent space between the tiles of a sprite sheet, against bleeding when the sheet is filtered.
+const spritePadding = 2
+
+// spriteRect and spriteSize are the rectangles and sizes of the TexturePacker JSON (Hash) format.
+type spriteRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type spriteSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// spriteFrame is the position of a tile in the sheet. Tiles are neither rotated nor trimmed.
+type spriteFrame struct {
+	Frame            spriteRect `json:"frame"`
+	Rotated          bool       `json:"rotated"`
+	Trimmed          bool       `json:"trimmed"`
+	SpriteSourceSize spriteRect `json:"spriteSourceSize"`
+	SourceSize       spriteSize `json:"sourceSize"`
+}
+
+type spriteMeta struct {
+	App    string     `json:"app"`
+	Image  string     `json:"image"`
+	Format string     `json:"format"`
+	Size   spriteSize `json:"size"`
+	Scale  string     `json:"scale"`
+}
+
+// spriteSheet is written as <page>_sprites.json next to the sheet (used by -sprite-sheet).
+type spriteSheet struct {
+	Frames map[string]spriteFrame `json:"frames"`
+	Meta   spriteMeta             `json:"meta"`
+}
+
+// writeSpriteSheet packs the decoded tiles of a page unscaled into <OutDir>/<name>_sprites.png and describes them in
+// <OutDir>/<name>_sprites.json in the TexturePacker JSON (Hash) format, which Unity and other engines import. The
+// frames are named like the tiles, <name>_<index>_<x>_<y>.
+func (f *File) writeSpriteSheet(name string, tiles []montageTile) error {
+	var decoded []montageTile
+	area := 0
+	widest := 0
+	for _, tile := range tiles {
+		if tile.img == nil {
+			continue
+		}
+		decoded = append(decoded, tile)
+		bounds := tile.img.Bounds()
+		area += (bounds.Dx() + spritePadding) * (bounds.Dy() + spritePadding)
+		widest = max(widest, bounds.Dx()+spritePadding)
+	}
+	if len(decoded) == 0 {
+		return nil
+	}
+
+	// Shelves of the tiles from the tallest to the lowest, on a roughly square sheet.
+	slices.SortStableFunc(decoded, func(a montageTile, b montageTile) int {
+		return b.img.Bounds().Dy() - a.img.Bounds().Dy()
+	})
+	sheetWidth := max(widest, int(math.Ceil(math.Sqrt(float64(area)))))
+
+	frames := map[string]spriteFrame{}
+	positions := make([]image.Point, len(decoded))
+	x, y, shelfHeight, sheetHeight := 0, 0, 0, 0
+	for n, tile := range decoded {
+		bounds := tile.img.Bounds()
+		if x > 0 && x+bounds.Dx() > sheetWidth {
+			x, y = 0, y+shelfHeight
+			shelfHeight = 0
+		}
+		positions[n] = image.Pt(x, y)
+		frameName := fmt.Sprintf("%v_%v_%v_%v", path.Base(name), tile.index, tile.info.GridPosW, tile.info.GridPosH)
+		frames[frameName] = spriteFrame{
+			Frame:            spriteRect{X: x, Y: y, W: bounds.Dx(), H: bounds.Dy()},
+			SpriteSourceSize: spriteRect{W: bounds.Dx(), H: bounds.Dy()},
+			SourceSize:       spriteSize{W: bounds.Dx(), H: bounds.Dy()},
+		}
+		x += bounds.Dx() + spritePadding
+		shelfHeight = max(shelfHeight, bounds.Dy()+spritePadding)
+		sheetHeight = max(sheetHeight, y+bounds.Dy())
+	}
+	sheetWidth = 0
+	for _, frame := range frames {
+		sheetWidth = max(sheetWidth, frame.Frame.X+frame.Frame.W)
+	}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, sheetWidth, sheetHeight))
+	for n, tile := range decoded {
+		bounds := tile.img.Bounds()
+		draw.Draw(sheet, bounds.Sub(bounds.Min).Add(positions[n]), tile.img, bounds.Min, draw.Src)
+	}
+
+	imageName := fmt.Sprintf("%v_sprites", name)
+	err := f.writeSlotPNG(path.Join(f.OutDir, imageName+".png"), sheet)
+	if err != nil {
+		return err
+	}
+	return f.writeJSON(imageName, spriteSheet{
+		Frames: frames,
+		Meta: spriteMeta{
+			App:    "playview-extractor",
+			Image:  path.Base(imageName) + ".png",
+			Format: "RGBA8888",
+			Size:   spriteSize{W: sheetWidth, H: sheetHeight},
+			Scale:  "1",
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package playview
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpriteSheet(t *testing.T) {
+	outDir := t.TempDir()
+	f := &File{Extractor: New(Options{OutDir: outDir})}
+
+	var tiles []montageTile
+	for n, size := range []image.Point{{X: 256, Y: 256}, {X: 88, Y: 256}, {X: 256, Y: 44}, {X: 88, Y: 44}} {
+		img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: uint8(n * 60), G: 0x80, A: 0xFF}), image.Point{}, draw.Src)
+		tiles = append(tiles, montageTile{index: n, info: ImageInfo{GridPosW: n % 2, GridPosH: n / 2}, img: img})
+	}
+	// Undecodable tiles are left out.
+	tiles = append(tiles, montageTile{index: 4, info: ImageInfo{GridPosW: 2}})
+
+	err := f.writeSpriteSheet("p001", tiles)
+	if err != nil {
+		t.Fatalf("unable to write sprite sheet: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "p001_sprites.json"))
+	if err != nil {
+		t.Fatalf("unable to read sprite sheet json: %v", err)
+	}
+	var sheet spriteSheet
+	err = json.Unmarshal(raw, &sheet)
+	if err != nil {
+		t.Fatalf("unable to decode sprite sheet json: %v", err)
+	}
+	file, err := os.Open(filepath.Join(outDir, "p001_sprites.png"))
+	if err != nil {
+		t.Fatalf("unable to open sprite sheet: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("unable to decode sprite sheet: %v", err)
+	}
+
+	if sheet.Meta.Image != "p001_sprites.png" || img.Bounds().Size() != image.Pt(sheet.Meta.Size.W, sheet.Meta.Size.H) {
+		t.Errorf("meta %+v does not match the %v sheet", sheet.Meta, img.Bounds().Size())
+	}
+	if len(sheet.Frames) != 4 {
+		t.Fatalf("got %v frames, expected 4", len(sheet.Frames))
+	}
+
+	var rects []image.Rectangle
+	for n, name := range []string{"p001_0_0_0", "p001_1_1_0", "p001_2_0_1", "p001_3_1_1"} {
+		frame, exists := sheet.Frames[name]
+		if !exists {
+			t.Fatalf("frame %v is missing", name)
+		}
+		rect := image.Rect(frame.Frame.X, frame.Frame.Y, frame.Frame.X+frame.Frame.W, frame.Frame.Y+frame.Frame.H)
+		if rect.Size() != tiles[n].img.Bounds().Size() || !rect.In(img.Bounds()) {
+			t.Errorf("frame %v is %v for a %v tile", name, rect, tiles[n].img.Bounds().Size())
+		}
+		for _, other := range rects {
+			if rect.Overlaps(other) {
+				t.Errorf("frame %v at %v overlaps %v", name, rect, other)
+			}
+		}
+		rects = append(rects, rect)
+		if got, want := color.RGBAModel.Convert(img.At(rect.Max.X-1, rect.Max.Y-1)), tiles[n].img.At(0, 0); got != want {
+			t.Errorf("frame %v has color %v, expected %v", name, got, want)
+		}
+	}
+}
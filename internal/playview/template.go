@@ -0,0 +1,83 @@
+package playview
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Placeholders like {page} in an output template.
+var templateFieldPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// Fields that can be used in an output template.
+var templateFields = []string{"page", "name", "book", "imageType", "index", "title"}
+
+// validateTemplate checks that an output template only uses known fields.
+func validateTemplate(template string) error {
+	for _, match := range templateFieldPattern.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, field := range templateFields {
+			known = known || field == match[1]
+		}
+		if !known {
+			return fmt.Errorf("unknown field %v, expected one of %v", match[0], strings.Join(templateFields, ", "))
+		}
+	}
+	return nil
+}
+
+// expandTemplate resolves the output template for page i into an output name (without extension).
+func (f *File) expandTemplate(i int) string {
+	values := map[string]string{
+		"page":      f.Pages[i].OutputName,
+		"name":      f.Pages[i].FileName,
+		"book":      f.book,
+		"imageType": f.Pages[i].ImageType,
+		"index":     fmt.Sprintf("%04d", i),
+		"title":     f.Pages[i].FileName,
+	}
+	if f.Pages[i].Title != "" {
+		values["title"] = titleFileName(f.Pages[i].Title)
+	}
+
+	name := templateFieldPattern.ReplaceAllStringFunc(f.OutTemplate, func(field string) string {
+		return values[field[1:len(field)-1]]
+	})
+	name, _ = strings.CutSuffix(name, ".png")
+
+	// Keep the output within OutDir.
+	return strings.TrimLeft(path.Clean("/"+name), "/")
+}
+
+// BookName guesses the name of a book from the path of its gvd.dat: the folder (or archive) holding it, or the file
+// name itself if it is not called gvd.dat.
+func BookName(filePath string) string {
+	if isRemotePath(filePath) {
+		return remoteBookName(filePath)
+	}
+
+	archivePath, entryName, isArchive := splitArchivePath(filePath)
+	if isArchive {
+		if base := path.Base(entryName); !strings.EqualFold(base, "gvd.dat") {
+			return strings.TrimSuffix(base, path.Ext(base))
+		}
+		if dir := path.Dir(entryName); dir != "." {
+			return path.Base(dir)
+		}
+		base := filepath.Base(archivePath)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	base := filepath.Base(filePath)
+	if !strings.EqualFold(base, "gvd.dat") {
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return "gvd"
+	}
+	return filepath.Base(dir)
+}
@@ -0,0 +1,49 @@
+package playview
+
+import (
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: "{page}", want: "0001_p001"},
+		{template: "{imageType}/{book}/{page}.png", want: "jpeg/ocean/0001_p001"},
+		{template: "{book}_{index}_{name}", want: "ocean_0002_p001"},
+		{template: "static/{name}", want: "static/p001"},
+		{template: "../../{name}", want: "p001"},
+		{template: "/abs/{name}", want: "abs/p001"},
+		{template: "a//b/./{name}", want: "a/b/p001"},
+	}
+	for _, test := range tests {
+		f := &File{
+			Extractor: New(Options{OutTemplate: test.template}),
+			book:      "ocean",
+			Pages:     make([]PageInfo, 3),
+		}
+		f.Pages[2] = PageInfo{FileName: "p001", OutputName: "0001_p001", ImageType: "jpeg"}
+
+		got := f.expandTemplate(2)
+		if got != test.want {
+			t.Errorf("expandTemplate(%q) = %q, expected %q", test.template, got, test.want)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	valid := []string{"", "{page}", "{imageType}/{book}/{page}.png", "{index}_{name}", "plain"}
+	for _, template := range valid {
+		if err := validateTemplate(template); err != nil {
+			t.Errorf("validateTemplate(%q) failed: %v", template, err)
+		}
+	}
+
+	invalid := []string{"{pages}", "{book}/{Page}", "{layer}"}
+	for _, template := range invalid {
+		if err := validateTemplate(template); err == nil {
+			t.Errorf("validateTemplate(%q) succeeded, expected an error", template)
+		}
+	}
+}
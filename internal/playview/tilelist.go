@@ -0,0 +1,55 @@
+package playview
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TileList selects tiles by their grid position, in every layer (used by -tile-list). If Include is empty, all tiles
+// but the excluded ones are exported. The zero value selects all tiles.
+type TileList struct {
+	Include map[image.Point]bool
+	Exclude map[image.Point]bool
+}
+
+// ReadTileList reads a list of grid positions, one "x,y" per line to include the tile or "-x,y" to exclude it. Empty
+// lines and lines starting with # are ignored.
+func ReadTileList(filePath string) (TileList, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return TileList{}, fmt.Errorf("unable to read tile list: %v", err)
+	}
+
+	list := TileList{Include: map[image.Point]bool{}, Exclude: map[image.Point]bool{}}
+	for n, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set := list.Include
+		if excluded, found := strings.CutPrefix(line, "-"); found {
+			set = list.Exclude
+			line = excluded
+		}
+		first, second, found := strings.Cut(line, ",")
+		x, errX := strconv.Atoi(strings.TrimSpace(first))
+		y, errY := strconv.Atoi(strings.TrimSpace(second))
+		if !found || errX != nil || errY != nil || x < 0 || y < 0 {
+			return TileList{}, fmt.Errorf("invalid grid position in line %v of %v: %q", n+1, filePath, line)
+		}
+		set[image.Pt(x, y)] = true
+	}
+	return list, nil
+}
+
+// Allows checks whether the tile at grid position x, y is selected.
+func (l TileList) Allows(x int, y int) bool {
+	at := image.Pt(x, y)
+	if l.Exclude[at] {
+		return false
+	}
+	return len(l.Include) == 0 || l.Include[at]
+}
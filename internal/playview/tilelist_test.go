@@ -0,0 +1,68 @@
+package playview
+
+import (
+	"image"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadTileList(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		filePath := filepath.Join(dir, "tiles.txt")
+		err := os.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("unable to write tile list: %v", err)
+		}
+		return filePath
+	}
+
+	list, err := ReadTileList(write("# corrupt in the last run\n1,0\n 0, 1 \n\n-1,0\r\n"))
+	if err != nil {
+		t.Fatalf("unable to read tile list: %v", err)
+	}
+	for _, test := range []struct {
+		x, y    int
+		allowed bool
+	}{{0, 1, true}, {1, 0, false}, {0, 0, false}, {1, 1, false}} {
+		if got := list.Allows(test.x, test.y); got != test.allowed {
+			t.Errorf("tile %v,%v allowed %v, expected %v", test.x, test.y, got, test.allowed)
+		}
+	}
+
+	list, err = ReadTileList(write("-0,0\n"))
+	if err != nil {
+		t.Fatalf("unable to read tile list: %v", err)
+	}
+	if list.Allows(0, 0) || !list.Allows(1, 0) {
+		t.Errorf("exclude only list allows 0,0 %v and 1,0 %v", list.Allows(0, 0), list.Allows(1, 0))
+	}
+	if !(TileList{}).Allows(3, 4) {
+		t.Errorf("empty tile list does not allow 3,4")
+	}
+
+	for _, content := range []string{"1\n", "a,b\n", "-1,-2\n", "1,2,3\n"} {
+		if _, err := ReadTileList(write(content)); err == nil {
+			t.Errorf("tile list %q was accepted", content)
+		}
+	}
+}
+
+func TestTileListExport(t *testing.T) {
+	filePath := writeTestBook(t, 1, 2)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	list := TileList{Exclude: map[image.Point]bool{image.Pt(0, 0): true, image.Pt(1, 1): true}}
+	e := New(Options{MergeImages: true, ValidateOnly: true, TileList: list})
+	err := e.ExtractFile(filePath)
+	if err != nil {
+		t.Fatalf("unable to extract: %v", err)
+	}
+	if e.Stats.DecodedTiles != 2 || e.Stats.FailedTiles != 0 {
+		t.Errorf("got %v decoded and %v failed tiles, expected 2 and 0", e.Stats.DecodedTiles, e.Stats.FailedTiles)
+	}
+}
@@ -0,0 +1,73 @@
+package playview
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+)
+
+// TMSLayer describes the tile grid of one layer of a page (used by -tms-layout).
+type TMSLayer struct {
+	Layer int `json:"layer"`
+
+	// Zoom level for map viewers, 0 for the lowest resolution layer.
+	Zoom    int `json:"zoom"`
+	Columns int `json:"columns"`
+	Rows    int `json:"rows"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Tiles   int `json:"tiles"`
+}
+
+// TMSMetadata is written as <page>/metadata.json next to the tiles of a page (used by -tms-layout).
+type TMSMetadata struct {
+	Name     string     `json:"name"`
+	TileSize int        `json:"tileSize"`
+	Layers   []TMSLayer `json:"layers"`
+}
+
+// writeTMSTile stores the data of a tile unchanged as <OutDir>/<page>/<layer>/<x>_<y>.jpg (or .png for png tiles).
+func (f *File) writeTMSTile(page PageInfo, img ImageInfo, data []byte) error {
+	filePath := path.Join(f.OutDir, page.OutputName, fmt.Sprint(img.Layer), fmt.Sprintf("%v_%v.%v", img.GridPosW, img.GridPosH, tileExtension(data)))
+
+	defer f.acquireWrite()()
+	err := createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write tile: %v", err)
+	}
+	return nil
+}
+
+// tmsMetadata describes the grid extents of the exported layers of a page, pageLayer is -1 for all layers.
+func tmsMetadata(page PageInfo, pageLayer int, pitchW int, pitchH int) TMSMetadata {
+	layers := page.Layers()
+	metadata := TMSMetadata{Name: page.FileName, TileSize: max(pitchW, pitchH)}
+
+	for _, layer := range layers {
+		if pageLayer != -1 && layer != pageLayer {
+			continue
+		}
+		grid := TMSLayer{Layer: layer, Zoom: layers[len(layers)-1] - layer}
+		for _, img := range page.Images {
+			if img.Layer != layer {
+				continue
+			}
+			grid.Tiles++
+			grid.Columns = max(grid.Columns, img.GridPosW+1)
+			grid.Rows = max(grid.Rows, img.GridPosH+1)
+			grid.Width = max(grid.Width, img.GridPosW*pitchW+img.Width)
+			grid.Height = max(grid.Height, img.GridPosH*pitchH+img.Height)
+		}
+		metadata.Layers = append(metadata.Layers, grid)
+	}
+
+	slices.SortStableFunc(metadata.Layers, func(a TMSLayer, b TMSLayer) int {
+		return a.Zoom - b.Zoom
+	})
+	return metadata
+}
@@ -0,0 +1,58 @@
+package playview
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTMSMetadata(t *testing.T) {
+	page := PageInfo{FileName: "p001", Images: []ImageInfo{
+		{GridPosW: 0, GridPosH: 0, Layer: 0, Width: 256, Height: 256},
+		{GridPosW: 1, GridPosH: 0, Layer: 0, Width: 88, Height: 256},
+		{GridPosW: 0, GridPosH: 1, Layer: 0, Width: 256, Height: 44},
+		{GridPosW: 0, GridPosH: 0, Layer: 1, Width: 172, Height: 150},
+	}}
+
+	metadata := tmsMetadata(page, -1, tilePitch, tilePitch)
+	want := []TMSLayer{
+		{Layer: 1, Zoom: 0, Columns: 1, Rows: 1, Width: 172, Height: 150, Tiles: 1},
+		{Layer: 0, Zoom: 1, Columns: 2, Rows: 2, Width: 344, Height: 300, Tiles: 3},
+	}
+	if !slices.Equal(metadata.Layers, want) {
+		t.Errorf("layers %+v, expected %+v", metadata.Layers, want)
+	}
+
+	metadata = tmsMetadata(page, 0, tilePitch, tilePitch)
+	if len(metadata.Layers) != 1 || metadata.Layers[0].Layer != 0 || metadata.Layers[0].Zoom != 1 {
+		t.Errorf("layers of layer 0 %+v, expected only layer 0 at zoom 1", metadata.Layers)
+	}
+}
+
+func TestViewer(t *testing.T) {
+	outDir := t.TempDir()
+	f := &File{Extractor: New(Options{OutDir: outDir})}
+	page := PageInfo{FileName: "p001", OutputName: "p001"}
+	metadata := TMSMetadata{Name: "<p001>", TileSize: tilePitch, Layers: []TMSLayer{{Columns: 2, Rows: 1, Width: 300, Height: 200, Tiles: 2}}}
+
+	err := f.writeViewer(page, metadata)
+	if err != nil {
+		t.Fatalf("unable to write viewer: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(outDir, "p001", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read viewer: %v", err)
+	}
+	html := string(raw)
+	for _, want := range []string{`<title>&lt;p001&gt;</title>`, `"tileSize":256`, `"columns":2`, `"width":300`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("viewer is missing %v", want)
+		}
+	}
+	if strings.Contains(html, "<p001>") {
+		t.Errorf("viewer contains the unescaped page name")
+	}
+}
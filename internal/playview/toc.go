@@ -0,0 +1,61 @@
+package playview
+
+import (
+	"io"
+	"strings"
+)
+
+// TOCParser reads the page titles from a table of contents embedded in a container, by the index of the page. The
+// container starts at offset base of r, the pages are known from its header. A parser returns no titles for a
+// container without a table of contents it knows.
+//
+// No layout of a table of contents is known so far, parsers for one can be added with RegisterTOCParser.
+type TOCParser func(r io.ReadSeeker, base int64, pages []PageInfo) (map[int]string, error)
+
+type registeredTOCParser struct {
+	name  string
+	parse TOCParser
+}
+
+var tocParsers []registeredTOCParser
+
+// RegisterTOCParser adds a parser for a table of contents. The parsers are tried in the order they were registered,
+// the first one finding titles wins.
+func RegisterTOCParser(name string, parse TOCParser) {
+	tocParsers = append(tocParsers, registeredTOCParser{name: name, parse: parse})
+}
+
+// readTitles sets the titles of the pages from the first table of contents found. A parser that fails is only
+// warned about, the pages are exported without titles then.
+func (f *File) readTitles() {
+	for _, parser := range tocParsers {
+		resume, _ := f.handle.Seek(0, 1)
+		titles, err := parser.parse(f.handle, f.base, f.Pages)
+		_, _ = f.handle.Seek(resume, 0)
+		if err != nil {
+			f.warnf("Unable to read the table of contents with %v: %v", parser.name, err)
+			continue
+		}
+		if len(titles) == 0 {
+			continue
+		}
+
+		for i, title := range titles {
+			if i >= 0 && i < len(f.Pages) {
+				f.Pages[i].Title = strings.TrimSpace(title)
+			}
+		}
+		f.logf(" >> %v page titles found by %v.", len(titles), parser.name)
+		return
+	}
+}
+
+// titleFileName replaces the characters of a title that cannot be part of a file name.
+func titleFileName(title string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, title)
+}
@@ -0,0 +1,38 @@
+package playview
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestTOCParser(t *testing.T) {
+	filePath := writeTestBook(t, 2, 1)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	RegisterTOCParser("test", func(r io.ReadSeeker, base int64, pages []PageInfo) (map[int]string, error) {
+		return map[int]string{1: " Chapter 1: The Sea/Shore "}, nil
+	})
+	defer func() {
+		tocParsers = tocParsers[:len(tocParsers)-1]
+	}()
+
+	f, err := New(Options{OutTemplate: "{title}"}).Open(filePath)
+	if err != nil {
+		t.Fatalf("unable to open book: %v", err)
+	}
+	defer f.Close()
+
+	if f.Pages[0].Title != "" || f.Pages[1].Title != "Chapter 1: The Sea/Shore" {
+		t.Fatalf("titles are %q and %q", f.Pages[0].Title, f.Pages[1].Title)
+	}
+	f.Pages[0].OutputName = f.Pages[0].FileName
+	if got := f.expandTemplate(0); got != f.Pages[0].FileName {
+		t.Errorf("page without title is named %q, expected %q", got, f.Pages[0].FileName)
+	}
+	if got, want := f.expandTemplate(1), "Chapter 1_ The Sea_Shore"; got != want {
+		t.Errorf("page with title is named %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package playview
+
+import (
+	"io"
+	"log"
+)
+
+// Names of the whence values of Seek.
+var whenceNames = map[int]string{
+	io.SeekStart:   "start",
+	io.SeekCurrent: "current",
+	io.SeekEnd:     "end",
+}
+
+// tracedInput logs every seek and read on an input with the resulting position (used by -trace-seeks).
+type tracedInput struct {
+	input
+}
+
+func (t tracedInput) Seek(offset int64, whence int) (int64, error) {
+	pos, err := t.input.Seek(offset, whence)
+	if offset == 0 && whence == io.SeekCurrent && err == nil {
+		// Only asks for the position (e.g. for the log), nothing moves.
+		return pos, nil
+	}
+	if err != nil {
+		log.Printf("[TRACE] seek %v from %v failed: %v", offset, whenceNames[whence], err)
+		return pos, err
+	}
+	log.Printf("[TRACE] seek %v from %v to 0x%X", offset, whenceNames[whence], pos)
+	return pos, nil
+}
+
+func (t tracedInput) Read(p []byte) (int, error) {
+	n, err := t.input.Read(p)
+	pos, _ := t.input.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Printf("[TRACE] read %v of %v bytes to 0x%X: %v", n, len(p), pos, err)
+		return n, err
+	}
+	log.Printf("[TRACE] read %v of %v bytes to 0x%X", n, len(p), pos)
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// rotateImage rotates an image clockwise by 0, 90, 180 or 270 degrees.
+func rotateImage(img image.Image, degrees int) image.Image {
+	if degrees == 0 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var rotated *image.RGBA
+	if degrees == 180 {
+		rotated = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		rotated = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			switch degrees {
+			case 90:
+				rotated.Set(h-1-y, x, c)
+			case 180:
+				rotated.Set(w-1-x, h-1-y, c)
+			case 270:
+				rotated.Set(y, w-1-x, c)
+			}
+		}
+	}
+
+	return rotated
+}
+
+// flipImage mirrors an image horizontally ("h") or vertically ("v").
+func flipImage(img image.Image, direction string) image.Image {
+	if direction == "" {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	flipped := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			if direction == "h" {
+				flipped.Set(w-1-x, y, c)
+			} else {
+				flipped.Set(x, h-1-y, c)
+			}
+		}
+	}
+
+	return flipped
+}
+
+// ScaleImage resizes an image to the given size using nearest neighbour sampling.
+func ScaleImage(img image.Image, width int, height int) *image.RGBA {
+	bounds := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	if bounds.Empty() {
+		return scaled
+	}
+
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			scaled.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+
+	return scaled
+}
+
+// FitSize returns the largest size with the aspect ratio of width x height that fits into maxWidth x maxHeight.
+func FitSize(width int, height int, maxWidth int, maxHeight int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return 0, 0
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// fitImage scales an image to fit into width x height, preserving its aspect ratio, and centers it on a canvas of
+// exactly that size filled with the background color.
+func fitImage(img image.Image, width int, height int, background color.Color, resampler string) *image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	bounds := img.Bounds()
+	scaledWidth, scaledHeight := FitSize(bounds.Dx(), bounds.Dy(), width, height)
+	if scaledWidth == 0 || scaledHeight == 0 {
+		return canvas
+	}
+
+	scaled := ResampleImage(img, scaledWidth, scaledHeight, resampler)
+	offset := image.Pt((width-scaledWidth)/2, (height-scaledHeight)/2)
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Over)
+
+	return canvas
+}
+
+// toRGBA64 converts an image to 16 bits per channel, so it is encoded as a 16-bit png.
+func toRGBA64(img image.Image) *image.RGBA64 {
+	bounds := img.Bounds()
+	converted := image.NewRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), img, bounds.Min, draw.Src)
+	return converted
+}
+
+// toRGBA converts an image to 8 bits per channel.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	converted := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), img, bounds.Min, draw.Src)
+	return converted
+}
+
+// whiteToAlpha makes the pixels of an image fully transparent whose color channels are all at most threshold below
+// white. Partly transparent pixels are compared by their color without the alpha.
+func whiteToAlpha(img *image.RGBA, threshold int) {
+	limit := 255 - threshold
+	for n := 0; n < len(img.Pix); n += 4 {
+		alpha := int(img.Pix[n+3])
+		if alpha == 0 {
+			continue
+		}
+		// The channels are premultiplied by alpha.
+		if int(img.Pix[n])*255 >= limit*alpha && int(img.Pix[n+1])*255 >= limit*alpha && int(img.Pix[n+2])*255 >= limit*alpha {
+			img.Pix[n], img.Pix[n+1], img.Pix[n+2], img.Pix[n+3] = 0, 0, 0, 0
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package playview
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestWhiteToAlpha(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+	img.SetRGBA(1, 0, color.RGBA{R: 0xF8, G: 0xFA, B: 0xFF, A: 0xFF})
+	img.SetRGBA(2, 0, color.RGBA{R: 0xF0, G: 0xFF, B: 0xFF, A: 0xFF})
+	img.SetRGBA(3, 0, color.RGBA{R: 0x7C, G: 0x7D, B: 0x80, A: 0x80}) // Half transparent near-white.
+	img.SetRGBA(4, 0, color.RGBA{R: 0x20, G: 0x40, B: 0x60, A: 0xFF})
+
+	whiteToAlpha(img, 8)
+
+	for x, want := range []bool{true, true, false, true, false} {
+		if transparent := img.RGBAAt(x, 0).A == 0; transparent != want {
+			t.Errorf("pixel %v (%v) transparent: %v, expected %v", x, img.RGBAAt(x, 0), transparent, want)
+		}
+	}
+}
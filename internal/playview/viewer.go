@@ -0,0 +1,128 @@
+package playview
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+)
+
+// Self-contained viewer written as <page>/index.html next to the tiles of -tms-layout (used by -html-viewer). It
+// draws the tiles of the layer matching the zoom onto a canvas, scroll to zoom, drag to pan, double click or 0 to fit.
+// The metadata is embedded, so opening the file from disk works without a web server.
+var viewerTemplate = template.Must(template.New("viewer").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+html, body { margin: 0; height: 100%; overflow: hidden; background: #333; }
+canvas { display: block; cursor: grab; }
+</style>
+</head>
+<body>
+<canvas id="page"></canvas>
+<script>
+const metadata = {{.}};
+const canvas = document.getElementById("page");
+const context = canvas.getContext("2d");
+
+// Layers from the lowest to the highest resolution, the last one has the size of the page.
+const layers = metadata.layers;
+const full = layers[layers.length - 1];
+const tiles = new Map();
+let scale = 1, offsetX = 0, offsetY = 0;
+
+// tile loads a tile once, trying .png if there is no .jpg, and redraws when it arrives.
+function tile(layer, x, y) {
+	const key = layer + "/" + x + "_" + y;
+	let img = tiles.get(key);
+	if (!img) {
+		img = new Image();
+		img.onload = draw;
+		img.onerror = () => {
+			if (img.src.endsWith(".jpg")) img.src = key + ".png";
+		};
+		img.src = key + ".jpg";
+		tiles.set(key, img);
+	}
+	return img;
+}
+
+// draw paints the lowest resolution layer that is still sharp at the current scale.
+function draw() {
+	context.fillStyle = "#333";
+	context.fillRect(0, 0, canvas.width, canvas.height);
+	const grid = layers.find((l) => l.width >= full.width * scale) || full;
+	const factor = full.width / grid.width * scale;
+	for (let y = 0; y < grid.rows; y++) {
+		for (let x = 0; x < grid.columns; x++) {
+			const img = tile(grid.layer, x, y);
+			if (!img.complete || img.naturalWidth === 0) continue;
+			const left = offsetX + x * metadata.tileSize * factor;
+			const top = offsetY + y * metadata.tileSize * factor;
+			context.drawImage(img, left, top, img.naturalWidth * factor, img.naturalHeight * factor);
+		}
+	}
+}
+
+function fit() {
+	canvas.width = window.innerWidth;
+	canvas.height = window.innerHeight;
+	scale = Math.min(canvas.width / full.width, canvas.height / full.height);
+	offsetX = (canvas.width - full.width * scale) / 2;
+	offsetY = (canvas.height - full.height * scale) / 2;
+	draw();
+}
+
+function zoom(factor, x, y) {
+	offsetX = x - (x - offsetX) * factor;
+	offsetY = y - (y - offsetY) * factor;
+	scale *= factor;
+	draw();
+}
+
+canvas.addEventListener("wheel", (event) => {
+	event.preventDefault();
+	zoom(event.deltaY < 0 ? 1.25 : 0.8, event.offsetX, event.offsetY);
+});
+canvas.addEventListener("mousemove", (event) => {
+	if (event.buttons !== 1) return;
+	offsetX += event.movementX;
+	offsetY += event.movementY;
+	draw();
+});
+canvas.addEventListener("dblclick", fit);
+window.addEventListener("keydown", (event) => {
+	if (event.key === "+") zoom(1.25, canvas.width / 2, canvas.height / 2);
+	if (event.key === "-") zoom(0.8, canvas.width / 2, canvas.height / 2);
+	if (event.key === "0") fit();
+});
+window.addEventListener("resize", fit);
+fit();
+</script>
+</body>
+</html>
+`))
+
+// writeViewer stores the viewer for the tiles of a page as <OutDir>/<page>/index.html.
+func (f *File) writeViewer(page PageInfo, metadata TMSMetadata) error {
+	var html bytes.Buffer
+	err := viewerTemplate.Execute(&html, metadata)
+	if err != nil {
+		return fmt.Errorf("unable to render viewer: %v", err)
+	}
+
+	filePath := path.Join(f.OutDir, page.OutputName, "index.html")
+	defer f.acquireWrite()()
+	err = createParentDir(filePath)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filePath, html.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write viewer: %v", err)
+	}
+	return nil
+}
@@ -7,558 +7,858 @@
 package main
 
 import (
-	"bytes"
-	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
-	"image/draw"
-	"image/jpeg"
-	"image/png"
+	"image/color"
+	"io"
 	"log"
 	"os"
-	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
-)
-
-// Configuration
-
-var MergeImages bool
-var TargetLayer int
-var TargetPage string
-var OutDir string
-var FilePath string
-var LogDebug bool
-var LoadFullImages bool
+	"time"
 
-// Global Data
-
-var totalDataEntries int
-var totalLengthFirstPart int
-
-type PageInfo struct {
-	// 0010 	4 	Offset file name.gvd (without header TGDT0100)
-	offsetFileName int
-
-	// 0014 	4 	Length file name.gvd (00 is not counted)
-	lengthFileName int
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
 
-	// 0018 	4 	Offset Data Base Viewer
-	offsetDataBaseViewer int
+// Exit codes
 
-	// 001C 	4 	Length Data base Viewer file
-	lengthDataBaseViewer int
+// All pages were extracted.
+const exitComplete = 0
 
-	fileName string
+// Nothing could be extracted, e.g. the header could not be parsed, or an output could not be written.
+const exitFailed = 1
 
-	imageWidth     int
-	imageHeight    int
-	lengthDatabase int
+// Invalid command line or option values.
+const exitUsage = 2
 
-	images []ImageInfo
+// Some pages or files failed, the others were extracted.
+const exitPartial = 3
 
-	lengthImages   int
-	paramLength    int
-	entranceLength int
-	imageType      string
-}
+// The files compared with -diff differ, or pages differ from their -reference.
+const exitDifferent = 4
 
-type ImageInfo struct {
-	gridPosW          int
-	gridPosH          int
-	height            int
-	width             int
-	fileLength        int
-	fileLengthPadding int
-	layer             int
-}
+// Configuration
 
-var pages []PageInfo
+var Options playview.Options
+var FilePaths []string
+var DiffPath string
+var Estimate bool
+var DumpLayout bool
+var ServeAddr string
+var Jobs int
+var ContentPath string
+var ContactSheetPath string
+var ContactSheetColumns int
+var ReferenceDir string
+var ReferenceThreshold int
+var Strict bool
+var ExtractionInfo bool
 
 func main() {
 
 	// Parse configuration.
 
 	mergeVal := flag.Bool("merge", true, "Whether to merge images to a combined image")
+	forceVal := flag.Bool("force", false, "only warn about a wrong header or marker and try to parse anyway")
+	gridOffsetVal := flag.String("grid-offset", "0,0", "pixel offset X,Y added to the position of every merged tile")
+	jpegDecoderVal := flag.String("jpeg-decoder", "std", "decoder for jpeg tiles (std, or turbo if built with -tags turbojpeg)")
+	extractionInfoVal := flag.Bool("extraction-info", false, "write an EXTRACTION_INFO.txt into the output with the version, flags, input files (hashed like -source-hash) and counts of the run")
+	sourceHashVal := flag.Bool("source-hash", false, "hash each input file before parsing it and record the SHA-256 in <out>/source.sha256 and the -sidecar files")
+	htmlViewerVal := flag.Bool("html-viewer", false, "write the tiles like -tms-layout with a <out>/<page>/index.html to zoom the page in a browser")
+	tmsLayoutVal := flag.Bool("tms-layout", false, "write the tiles unchanged as <out>/<page>/<layer>/<x>_<y>.jpg with a metadata.json of the grid per layer instead of single png tiles, for tiled map viewers")
+	readRetriesVal := flag.Int("read-retries", 0, "retry a failed read this often with a short backoff before giving up on the page, for files on network shares")
+	maxTileBytesVal := flag.Int("max-tile-bytes", 64<<20, "skip tiles with more data than this, as their length field is corrupt (0 for no limit)")
+	tilesVal := flag.Bool("tiles", false, "also save each tile when merging")
+	rawTilesVal := flag.Bool("raw-tiles", false, "save the tiles with their embedded jpeg data unchanged as <tile>.jpg instead of decoding them to png")
+	maxMegapixelsVal := flag.Float64("max-megapixels", 0, "scale merged pages larger than this many million pixels down to fit, keeping their aspect ratio (0 keeps their size)")
+	previewWidthVal := flag.Int("preview-width", 0, "scale merged pages down to at most this width, e.g. 1200 for previews next to -tiles -raw-tiles (0 keeps their size)")
+	fillFromLayerVal := flag.Bool("fill-from-layer", false, "fill the grid cells missing in the -layer from the nearest other layer, scaled to fit")
+	layerOrderVal := flag.String("layer-order", "file", "order in which the tiles of several layers are merged: file, asc (layer 0 first) or desc (layer 0 last, on top)")
+	layersSeparateVal := flag.Bool("layers-separate", false, "merge each layer into its own image in <out>/layer_<n>/")
 	targetLayerVal := flag.Int("layer", 0, "Target layer to export")
 	targetPageVal := flag.String("page", "", "Target page to export (empty string exports all)")
-	outDirVal := flag.String("out", "out", "output directory")
-	inVal := flag.String("in", "gvd.dat", "path to gvd.dat")
-	logVal := flag.Bool("debug", false, "output more log data")
+	targetPageGlobVal := flag.String("page-glob", "", "Pattern of target pages to export, e.g. \"chapter1_*\" (empty string exports all)")
+	orderVal := flag.String("order", "table", "order of the exported pages: table (as in the file) or name (natural sort), numbers the output with name")
+	outDirVal := flag.String("out", "out", "output directory, a .cbz archive, or a URL like file:///srv/pages")
+	outTemplateVal := flag.String("out-template", "", "output path of each page within the output directory, e.g. \"{imageType}/{book}/{page}.png\" (fields: page, name, book, imageType, index, title)")
+	writeJobsVal := flag.Int("write-jobs", 0, "number of files written at the same time, e.g. 1 for spinning disks (0 for no limit)")
+	jobsVal := flag.Int("jobs", 1, "number of input files extracted concurrently, each into <out>/<book>/")
+	urlVal := flag.String("url", "", "http(s) URL of a gvd.dat to read with range requests instead of -in, only the needed parts are downloaded")
+	inVal := flag.String("in", "gvd.dat", "path to gvd.dat, or archive.zip:gvd.dat to read it from a zip (comma separated to extract several files in order)")
+	traceSeeksVal := flag.Bool("trace-seeks", false, "log every seek and read on the input with the resulting position (verbose, for diffing against a known-good trace)")
+	debugGridVal := flag.Bool("debug-grid", false, "write a <page>_grid.png with the outline and index of every merged tile")
+	logVal := flag.Bool("debug", false, "output more log data (same as -v 3)")
+	quietVal := flag.Bool("quiet", false, "log warnings and errors only, for scripts (same as -v 0)")
+	strictVal := flag.Bool("strict", false, "exit with status 3 if there was any warning, even if all pages were extracted")
+	verbosityVal := flag.Int("v", 1, "log level: 0 for warnings and errors only, 1 for the progress of every page, 2 to add the header and page fields, 3 to add every tile")
+	sidecarVal := flag.Bool("sidecar", false, "write a <page>.json with the page metadata next to each merged page")
+	hexDumpVal := flag.Bool("hexdump", false, "log a hex dump of the bytes around the offset when a marker does not match")
 	showHiddenImagesVal := flag.Bool("hidden", true, "whether to show the hidden areas")
+	diffVal := flag.String("diff", "", "path to a second gvd.dat to compare the structure against")
+	overlapVal := flag.String("overlap", "last", "which of overlapping tiles is merged: first, last or skip (none)")
+	referenceVal := flag.String("reference", "", "folder with the pages of an earlier run to compare each merged page against, fails if one differs")
+	referenceThresholdVal := flag.Int("reference-threshold", 0, "largest difference of a color channel (0-255) allowed by -reference")
+	resampleVal := flag.String("resample", "nearest", "resampler where merged pages are resized (-fit, -preview-width, -max-megapixels, -align-layers, -zoom-anim, -fill-from-layer, -contact-sheet): nearest, bilinear or catmullrom")
+	resumeVal := flag.Bool("resume", false, "record the completed pages in <out>/.playview-progress.json and skip the ones completed by an earlier run")
+	rotateVal := flag.Int("rotate", 0, "rotate merged pages clockwise by 0, 90, 180 or 270 degrees")
+	estimateVal := flag.Bool("estimate", false, "only parse the structure and print the projected output size for the chosen options")
+	layoutVal := flag.Bool("layout", false, "only print the offsets, lengths and values of the fields of the header and the first page as JSON")
+	fitVal := flag.String("fit", "", "scale merged pages to fit into WxH and pad them to exactly that size, e.g. \"1072x1448\"")
+	fitBackgroundVal := flag.String("fit-background", "ffffff", "color RRGGBB of the padding added by -fit")
+	flipVal := flag.String("flip", "", "flip merged pages horizontally (h) or vertically (v)")
+	zoomAnimVal := flag.Bool("zoom-anim", false, "export an animated png per page that zooms through all layers")
+	spreadVal := flag.Bool("spread", false, "join each two consecutive merged pages side by side into <first>_<second>.png, like the book is read")
+	spreadStartVal := flag.String("spread-start", "odd", "first page of the spreads: odd (pages 1+2, 3+4, ...) or even (page 1 alone, then 2+3, ...)")
+	spreadRTLVal := flag.Bool("spread-rtl", false, "put the first page of each spread on the right, for right to left books")
+	splitChannelsVal := flag.Bool("split-channels", false, "also save the r, g, b and a channel of every merged page as grayscale <page>_<r|g|b|a>.png")
+	whiteToAlphaVal := flag.Bool("white-to-alpha", false, "make the near-white pixels of merged pages transparent, see -white-threshold")
+	whiteThresholdVal := flag.Int("white-threshold", 8, "largest distance from white (0-255) of every color channel for -white-to-alpha, 0 for pure white only")
+	spreadGutterVal := flag.Int("spread-gutter", 0, "width in pixels of the binding gutter between the pages of a spread")
+	scanModeVal := flag.Bool("scan-mode", false, "for damaged files: carve out every jpeg by its markers instead of trusting the length fields, exported unmerged as scan_<n>_<offset>.png")
+	serveVal := flag.String("serve", "", "serve the merged pages via http at this address, e.g. \":8080\"")
+	thumbsOnlyVal := flag.Bool("thumbs-only", false, "only export the single tile thumbnail of each page (the lowest resolution layer)")
+	spriteSheetVal := flag.Bool("sprite-sheet", false, "pack the tiles of each page into a <page>_sprites.png with a TexturePacker JSON (Hash) <page>_sprites.json for game engines")
+	tileMontageVal := flag.Bool("tile-montage", false, "write a <page>_layer_<n>_tiles.png per exported layer with the single tiles in grid order and their index (-layer -1 for all layers)")
+	validateOnlyVal := flag.Bool("validate-only", false, "only check that all tiles decode, nothing is written")
+	tileListVal := flag.String("tile-list", "", "file with the grid positions of the tiles to export, one x,y per line, or -x,y to leave a tile out")
+	clipVal := flag.String("clip", "", "crop merged pages to the region X,Y,W,H (tiles outside of it are not decoded)")
+	contactSheetVal := flag.String("contact-sheet", "", "path of a png with thumbnails of all merged pages")
+	contactColumnsVal := flag.Int("contact-columns", 8, "number of columns of the contact sheet")
+	configVal := flag.String("config", "", "path to a playview.toml with one flag = value per line, flags on the command line take precedence")
+	contentVal := flag.String("content", "", "path to a content.dat to check that every listed page was exported")
+	alignLayersVal := flag.Bool("align-layers", false, "merge every layer on its own and upscale it to the size of layer 0")
+	bitDepthVal := flag.Int("bitdepth", 8, "bits per channel of the merged pages: 8, or 16 for archival masters")
+	autoFixVal := flag.Bool("auto-fix", false, "swap width and height of pages whose tiles cover the transposed page size")
+	autoCropVal := flag.Bool("autocrop", false, "crop suspiciously large pages to the area covered by tiles")
+	autoPitchVal := flag.Bool("auto-pitch", false, "use the size of the first decoded tile as grid stride")
+	multiContainerVal := flag.Bool("multi-container", false, "also export further TGDT0100 containers appended to the file, into <out>/container_<n>/")
+	dualOnlyVal := flag.Bool("dual-only", false, "only export the pages with gvmp dual images")
+	dpiVal := flag.Int("dpi", 0, "resolution stored in the exported png files, e.g. 300 for print (0 stores none)")
+	dualNamesVal := flag.Bool("dual-names", false, "name the tiles of gvmp pages <page>_layer_<n>_<A|B>_<index>_<x>_<y>, A for the first and B for the hidden image")
+	dumpDatabaseViewerVal := flag.Bool("dump-dbviewer", false, "also write the database of each page verbatim to <page>.dbv, before it is parsed")
+	dumpBlocksVal := flag.Bool("dump-blocks", false, "also write every BLK_ section of each page database to <page>_blk_<n>.bin")
+	pixelsVal := flag.Bool("pixels", false, "write uncompressed RGBA pixel dumps (<name>.bin, see README) instead of png files")
+	dedupVal := flag.Bool("dedup", false, "export identical tiles only once when not merging")
+	noMergeRawVal := flag.Bool("no-merge-raw", false, "do not export undecodable tiles as raw files when merging")
 
 	flag.Parse()
 
-	if mergeVal != nil {
-		MergeImages = *mergeVal
+	// Flags after the first positional argument are not parsed, so reject anything left over.
+	if flag.NArg() > 0 {
+		fmt.Fprintf(flag.CommandLine.Output(), "unexpected arguments: %v\n", strings.Join(flag.Args(), " "))
+		flag.Usage()
+		exit(exitUsage)
 	}
 
-	if targetLayerVal != nil {
-		TargetLayer = *targetLayerVal
+	if configVal != nil && *configVal != "" {
+		err := loadConfig(flag.CommandLine, *configVal)
+		if err != nil {
+			failUsage("%v", err)
+		}
 	}
 
-	if targetPageVal != nil {
-		TargetPage = *targetPageVal
+	if mergeVal != nil {
+		Options.MergeImages = *mergeVal
 	}
 
-	if outDirVal != nil {
-		OutDir = *outDirVal
+	if forceVal != nil {
+		Options.Force = *forceVal
 	}
 
-	if inVal != nil {
-		FilePath = *inVal
+	if gridOffsetVal != nil {
+		var err error
+		Options.GridOffsetX, Options.GridOffsetY, err = parsePair(*gridOffsetVal)
+		if err != nil {
+			failUsage("invalid grid offset: %v", err)
+		}
 	}
 
-	if logVal != nil {
-		LogDebug = *logVal
+	if jpegDecoderVal != nil {
+		Options.JPEGDecoder = *jpegDecoderVal
 	}
 
-	if showHiddenImagesVal != nil {
-		LoadFullImages = *showHiddenImagesVal
+	if sourceHashVal != nil {
+		Options.SourceHash = *sourceHashVal
 	}
 
-	// Start application.
-	if _, err := os.Stat(OutDir); err != nil {
-		// Check if the output folder exists.
-		err := os.Mkdir(OutDir, os.ModeDir)
-		if err != nil {
-			panic(err)
-		}
+	if extractionInfoVal != nil && *extractionInfoVal {
+		// The info lists the hash of every input file.
+		ExtractionInfo = true
+		Options.SourceHash = true
 	}
 
-	gvdHandle, err := os.Open(FilePath)
-	if err != nil {
-		panic(err)
+	if tmsLayoutVal != nil {
+		Options.TMSLayout = *tmsLayoutVal
 	}
 
-	err = readHeader(gvdHandle)
-	if err != nil {
-		panic(err)
+	if htmlViewerVal != nil && *htmlViewerVal {
+		Options.HTMLViewer = true
+		Options.TMSLayout = true
 	}
 
-	err = readFileNames(gvdHandle)
-	if err != nil {
-		panic(err)
+	if maxTileBytesVal != nil {
+		Options.MaxTileBytes = *maxTileBytesVal
 	}
 
-	err = readDatabase(gvdHandle)
-	if err != nil {
-		log.Panicf("unable to read databases: %v", err)
+	if readRetriesVal != nil {
+		if *readRetriesVal < 0 {
+			failUsage("invalid read retries: %v", *readRetriesVal)
+		}
+		Options.ReadRetries = *readRetriesVal
 	}
 
-	log.Print("done")
-}
-
-func readDatabase(f *os.File) error {
+	if tilesVal != nil {
+		Options.ExportTiles = *tilesVal
+	}
 
-	for i := int(0); i < totalDataEntries; i++ {
+	if rawTilesVal != nil {
+		Options.RawTiles = *rawTilesVal
+	}
 
-		// Only export the requested pages.
-		if TargetPage != "" && TargetPage != pages[i].fileName {
-			continue
-		}
+	if previewWidthVal != nil {
+		Options.PreviewWidth = *previewWidthVal
+	}
 
-		log.Printf("  > Handle [%v]", pages[i].fileName)
+	if maxMegapixelsVal != nil {
+		Options.MaxMegapixels = *maxMegapixelsVal
+	}
 
-		// Jump to database.
-		_, _ = f.Seek(int64(totalLengthFirstPart+pages[i].offsetDataBaseViewer), 0)
+	if fillFromLayerVal != nil {
+		Options.FillFromLayer = *fillFromLayerVal
+	}
 
-		key, _ := readString(f, 16)
-		if key == "GVEW0100JPEG0100" {
-			pages[i].imageType = "jpeg"
-		} else if key == "GVEW0100GVMP0100" {
-			pages[i].imageType = "gvmp"
-		} else {
-			log.Panicf("unknown database type: %v", key)
-		}
+	if layerOrderVal != nil {
+		Options.LayerOrder = *layerOrderVal
+	}
 
-		log.Printf("   .. Type [%v]", pages[i].imageType)
+	if layersSeparateVal != nil {
+		Options.SeparateLayers = *layersSeparateVal
+	}
 
-		// Read Length
-		pages[i].imageWidth, _ = readUint32(f)
+	if targetLayerVal != nil {
+		Options.TargetLayer = *targetLayerVal
+	}
 
-		// Read Heigth
-		pages[i].imageHeight, _ = readUint32(f)
+	if targetPageVal != nil {
+		Options.TargetPage = *targetPageVal
+	}
 
-		// Read BLK
-		readCompare(f, []byte{0x42, 0x4C, 0x4B, 0x5F})
+	if targetPageGlobVal != nil {
+		Options.TargetPageGlob = *targetPageGlobVal
+	}
 
-		// Length Database
-		pages[i].lengthDatabase, _ = readUint32(f)
+	if orderVal != nil {
+		Options.Order = *orderVal
+	}
 
-		// DATABASES START
-		readCompare(f, []byte{00, 00, 00, 01, 00, 00, 00, 00})
+	if outDirVal != nil {
+		Options.OutDir = *outDirVal
+	}
 
-		// 0028 	4 	00 00 00 20 	each entrance length: 0X20
-		pages[i].entranceLength, _ = readUint32(f)
-		// readCompare(f, []byte{0x00, 0x00, 0x00, 0x20})
+	if outTemplateVal != nil {
+		Options.OutTemplate = *outTemplateVal
+	}
 
-		// 002C 	4 	00 00 00 04 	each parameter length: 0X04
-		pages[i].paramLength, _ = readUint32(f)
-		// readCompare(f, []byte{0x00, 0x00, 0x00, 0x04})
+	if jobsVal != nil {
+		Jobs = *jobsVal
+	}
 
-		if LogDebug {
-			log.Printf("[%v] length: %v", i, pages[i].imageWidth)
-			log.Printf("[%v] height: %v", i, pages[i].imageHeight)
-			log.Printf("[%v] lengthDatabase: %v", i, pages[i].lengthDatabase)
-			log.Printf("[%v] entryLength: %v", i, pages[i].entranceLength)
-			log.Printf("[%v] paramLength: %v", i, pages[i].paramLength)
-		}
+	if writeJobsVal != nil && *writeJobsVal > 0 {
+		Options.WriteSlots = make(chan struct{}, *writeJobsVal)
+	}
 
-		// Read images
-		numImages := pages[i].lengthDatabase / pages[i].entranceLength
-		pages[i].images = make([]ImageInfo, numImages)
-
-		for j := int(0); j < numImages; j++ {
-
-			if pages[i].paramLength == 4 {
-				// 0030 	4 	00 00 00 xx 	Grid position Width (hex): as horizontal line, left to right.
-				pages[i].images[j].gridPosW, _ = readUint32(f)
-				// 0034 	4 	00 00 00 xx 	Grid position Height (hex): next position after each horizontal line.
-				pages[i].images[j].gridPosH, _ = readUint32(f)
-				// 0038 	4 	00 00 00 0x 	Layer level: layer 0 (max zoom) appear first.
-				pages[i].images[j].layer, _ = readUint32(f)
-				// 003C 	4 	00 00 xx xx 	Length of the image (hex)
-				pages[i].images[j].fileLength, _ = readUint32(f)
-				// 0040 	4 	00 00 00 xx 	Length padding of the image (hex)
-				pages[i].images[j].fileLengthPadding, _ = readUint32(f)
-				// 0044 	4 	00 00 00 00 	Not used?
-				_, _ = readUint32(f)
-				// 0048 	4 	00 00 0x xx 	Width image (hex)
-				pages[i].images[j].width, _ = readUint32(f)
-				// 004C 	4 	00 00 0x xx 	Height image (hex)
-				pages[i].images[j].height, _ = readUint32(f)
-			} else {
-				panic("not implemented")
-			}
+	if inVal != nil {
+		FilePaths = strings.Split(*inVal, ",")
+	}
 
-			if LogDebug {
-				log.Printf("   > %#v", pages[i].images[j])
+	if flag.NFlag() == 0 {
+		if _, err := os.Stat(FilePaths[0]); errors.Is(err, os.ErrNotExist) {
+			// Started without any flags and nothing to extract, e.g. by a double click, so ask for the file.
+			filePath, found := askForInput()
+			if !found {
+				exit(exitUsage)
 			}
+			FilePaths = []string{filePath}
 		}
+	}
 
-		// Read BLK
-		readCompare(f, []byte{0x42, 0x4C, 0x4B, 0x5F})
+	if urlVal != nil && *urlVal != "" {
+		FilePaths = []string{*urlVal}
+	}
 
-		// XXXX 	4 	xx xx xx xx 	Total length embedded images (with FF padding)
-		pages[i].lengthImages, _ = readUint32(f)
+	if debugGridVal != nil {
+		Options.DebugGrid = *debugGridVal
+	}
 
-		if LogDebug {
-			log.Printf("[%v] lengthImages: %v", i, pages[i].lengthImages)
+	if verbosityVal != nil {
+		if *verbosityVal < 0 || *verbosityVal > 3 {
+			failUsage("invalid log level: %v", *verbosityVal)
 		}
+		// The zero value of the log level is the default -v 1.
+		Options.LogLevel = *verbosityVal - 1
+	}
 
-		// START IMAGES
-		readCompare(f, []byte{00, 00, 00, 02, 00, 00, 00, 00})
+	if logVal != nil && *logVal {
+		Options.LogLevel = playview.LogTrace
+	}
 
-		// Create a new image
-		mergedImage := image.NewRGBA(image.Rect(0, 0, pages[i].imageWidth, pages[i].imageHeight))
+	if quietVal != nil && *quietVal {
+		Options.LogLevel = playview.LogQuiet
+	}
 
-		// Detect overlaps.
-		handled := map[string]bool{}
+	if strictVal != nil {
+		Strict = *strictVal
+	}
 
-		// Track if any data has been added.
-		hasAnyImageData := false
+	if traceSeeksVal != nil {
+		Options.TraceSeeks = *traceSeeksVal
+	}
 
-		var rawImage []byte
+	if hexDumpVal != nil {
+		Options.HexDump = *hexDumpVal
+	}
 
-		for j := 0; j < numImages; j++ {
+	if showHiddenImagesVal != nil {
+		Options.LoadFullImages = *showHiddenImagesVal
+	}
 
-			// Skip if not the targeted layer.
-			layer := pages[i].images[j].layer
-			if TargetLayer != -1 && layer != TargetLayer {
-				_, _ = f.Seek(int64(pages[i].images[j].fileLength+pages[i].images[j].fileLengthPadding), 1)
-				continue
-			}
+	if sidecarVal != nil {
+		Options.WriteSidecar = *sidecarVal
+	}
 
-			posW := pages[i].images[j].gridPosW
-			posH := pages[i].images[j].gridPosH
+	if noMergeRawVal != nil {
+		Options.SkipMergeRaw = *noMergeRawVal
+	}
 
-			if LogDebug {
-				log.Printf("")
-				log.Printf("Image %v at %v;%v", j, posW, posH)
-			}
+	if overlapVal != nil {
+		Options.OverlapMode = *overlapVal
+	}
 
-			if pages[i].imageType == "gvmp" {
-				// [Dual Image]
-
-				if LogDebug {
-					pos, _ := f.Seek(0, 1)
-					log.Printf(" POS-BEFORE %v", pos)
-				}
-
-				readCompare(f, []byte{0x47, 0x56, 0x4D, 0x50}) // Header "GVMP".
-				readCompare(f, []byte{0x00, 0x00, 0x00, 0x02}) // Unused ? Maybe number of images? 2
-				readCompare(f, []byte{0x00, 0x00, 0x00, 0x20}) // Unused ? Maybe header length? 32
-				imageLength, _ := readUint32(f)                // file length
-				paddedImageLength, _ := readUint32(f)          // Only if paddedImageLength != 32
-				secondImageLength, _ := readUint32(f)          // Only if paddedImageLength != 32
-				readCompare(f, []byte{0x00, 0x00, 0x00, 0x00}) // Unused ? Maybe padding? 0
-				readCompare(f, []byte{0x00, 0x00, 0x00, 0x00}) // Unused ? Maybe padding? 0
-
-				if LogDebug {
-					log.Printf("(A) %v; %v; %v", imageLength, paddedImageLength, secondImageLength)
-				}
-
-				// Skip first image by jumping the original file length.
-				if LoadFullImages && paddedImageLength != 32 {
-					_, _ = f.Seek(int64(paddedImageLength-32), 1)
-					imageLength = secondImageLength
-				}
-
-				rawImage, _ = readBytes(f, imageLength)
-
-				if !LoadFullImages && paddedImageLength != 32 {
-					// Move by the first padding.
-					_, _ = f.Seek(int64(paddedImageLength-imageLength-32), 1)
-					// Move by the second image.
-					_, _ = f.Seek(int64(secondImageLength), 1)
-				}
-
-				// Align to next 16 byte block.
-				pos, _ := f.Seek(0, 1)
-				paddingOffset := pos % 16
-				if paddingOffset != 0 {
-					_, _ = f.Seek(16-paddingOffset, 1)
-				}
-
-			} else {
-				// [Regular Image]
-
-				// Load the image.
-				rawImage, _ = readBytes(f, pages[i].images[j].fileLength)
-			}
+	if referenceVal != nil {
+		ReferenceDir = *referenceVal
+	}
 
-			singleImage, err := jpeg.Decode(bytes.NewBuffer(rawImage))
-			if err != nil {
-				// [Not an image]
-
-				// Export raw for analysis.
-				rawFile, err := os.Create(path.Join(OutDir, fmt.Sprintf("%v_%v.raw", pages[i].fileName, j)))
-				if err != nil {
-					return fmt.Errorf("unable to open file: %v", err)
-				}
-				_, writeErr := rawFile.Write(rawImage)
-				if writeErr != nil {
-					return fmt.Errorf("unable to write raw data: %v", err)
-				}
-				closeErr := rawFile.Close()
-				if closeErr != nil {
-					return fmt.Errorf("unable to close output file: %v", err)
-				}
-
-			} else {
-				// [Image]
-				hasAnyImageData = true
-
-				// Check if a file is overlapping.
-				handleKey := fmt.Sprintf("%v-%v", pages[i].images[j].gridPosW, pages[i].images[j].gridPosH)
-				if _, exists := handled[handleKey]; exists {
-					log.Printf("  [WARNING] Overlapping image at %, %v detected.", pages[i].images[j].gridPosW, pages[i].images[j].gridPosH)
-				}
-				handled[handleKey] = true
-
-				if MergeImages {
-					// [Build the merged image]
-					x := posW * 256
-					y := posH * 256
-					bounds := singleImage.Bounds()
-					draw.Draw(mergedImage, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), singleImage, bounds.Min, draw.Over)
-				} else {
-					// [Save each image without merging]
-					imgFile, err := os.Create(path.Join(OutDir, fmt.Sprintf("%v_%v_%v_%v.png", pages[i].fileName, j, posW, posH)))
-					if err != nil {
-						return fmt.Errorf("unable to open file: %v", err)
-					}
-					err = jpeg.Encode(imgFile, singleImage, nil)
-					if err != nil {
-						return fmt.Errorf("unable to encode png: %v", err)
-					}
-					closeErr := imgFile.Close()
-					if closeErr != nil {
-						return fmt.Errorf("unable to close output file: %v", err)
-					}
-				}
-
-				// Skip padding.
-				_, _ = f.Seek(int64(pages[i].images[j].fileLengthPadding), 1)
-			}
+	if referenceThresholdVal != nil {
+		ReferenceThreshold = *referenceThresholdVal
+		if ReferenceThreshold < 0 || ReferenceThreshold > 255 {
+			failUsage("invalid reference threshold: %v", ReferenceThreshold)
 		}
+	}
 
-		if MergeImages && hasAnyImageData {
+	if resampleVal != nil {
+		Options.Resample = *resampleVal
+	}
 
-			// [Save the merged image]
-			imgFile, err := os.Create(path.Join(OutDir, fmt.Sprintf("%v.png", pages[i].fileName)))
-			if err != nil {
-				return fmt.Errorf("unable to open file: %v", err)
-			}
-			err = png.Encode(imgFile, mergedImage)
-			if err != nil {
-				return fmt.Errorf("unable to encode jpeg: %v", err)
-			}
-			closeErr := imgFile.Close()
-			if closeErr != nil {
-				return fmt.Errorf("unable to close output file: %v", err)
-			}
+	if resumeVal != nil {
+		Options.Resume = *resumeVal
+	}
+
+	if rotateVal != nil {
+		Options.RotateDegrees = *rotateVal
+	}
+
+	if fitVal != nil && *fitVal != "" {
+		var err error
+		Options.FitWidth, Options.FitHeight, err = parseSize(*fitVal)
+		if err != nil {
+			failUsage("invalid fit size: %v", err)
 		}
+	}
 
-		log.Printf("   .. Exported")
+	if fitBackgroundVal != nil {
+		var err error
+		Options.FitBackground, err = parseColor(*fitBackgroundVal)
+		if err != nil {
+			failUsage("invalid fit background: %v", err)
+		}
 	}
 
-	log.Printf(" >> Databases done.")
+	if flipVal != nil {
+		Options.FlipDirection = *flipVal
+	}
 
-	return nil
-}
+	if zoomAnimVal != nil {
+		Options.ZoomAnimation = *zoomAnimVal
+	}
 
-func readCompare(f *os.File, b []byte) {
-	raw, _ := readBytes(f, len(b))
-	if bytes.Compare(raw, b) != 0 {
-		log.Panicf("does not compare: %v <> %v", raw, b)
+	if serveVal != nil {
+		ServeAddr = *serveVal
+	}
+
+	if thumbsOnlyVal != nil {
+		Options.ThumbsOnly = *thumbsOnlyVal
+	}
+
+	if tileMontageVal != nil {
+		Options.TileMontage = *tileMontageVal
+	}
+
+	if spriteSheetVal != nil {
+		Options.SpriteSheet = *spriteSheetVal
+	}
+
+	if pixelsVal != nil {
+		Options.RawPixels = *pixelsVal
+	}
+
+	if dualOnlyVal != nil {
+		Options.DualOnly = *dualOnlyVal
 	}
-}
 
-func readFileNames(f *os.File) error {
-	for i := int(0); i < totalDataEntries; i++ {
+	if validateOnlyVal != nil {
+		Options.ValidateOnly = *validateOnlyVal
+	}
 
-		_, err := f.Seek(int64(totalLengthFirstPart+pages[i].offsetFileName), 0)
+	if clipVal != nil && *clipVal != "" {
+		var err error
+		Options.Clip, err = parseRect(*clipVal)
 		if err != nil {
-			return fmt.Errorf("unable to seek: %v", err)
+			failUsage("invalid clip region: %v", err)
 		}
+	}
 
-		nextName, err := readString(f, int(pages[i].lengthFileName))
+	if tileListVal != nil && *tileListVal != "" {
+		var err error
+		Options.TileList, err = playview.ReadTileList(*tileListVal)
 		if err != nil {
-			return fmt.Errorf("unable to read filename %v : %v", i, err)
+			failUsage("%v", err)
 		}
-		pages[i].fileName, _ = strings.CutSuffix(nextName, ".gvd")
+	}
 
-		if LogDebug {
-			log.Printf(" > %v", nextName)
-		}
+	if contactSheetVal != nil {
+		ContactSheetPath = *contactSheetVal
 	}
 
-	log.Printf(" >> File names done.")
+	if contactColumnsVal != nil {
+		ContactSheetColumns = *contactColumnsVal
+	}
 
-	return nil
-}
+	if contentVal != nil {
+		ContentPath = *contentVal
+	}
 
-func readHeader(f *os.File) error {
+	if alignLayersVal != nil {
+		Options.AlignLayers = *alignLayersVal
+	}
 
-	// 0000 8 "TGDT0100"
-	expectedHeader := "TGDT0100"
+	if bitDepthVal != nil {
+		Options.BitDepth = *bitDepthVal
+	}
 
-	log.Printf("Checking header %s", expectedHeader)
-	TGDHeader, err := readString(f, 8)
-	if err != nil {
-		return err
+	if autoCropVal != nil {
+		Options.AutoCrop = *autoCropVal
 	}
 
-	if strings.Compare(TGDHeader, expectedHeader) != 0 {
-		return fmt.Errorf("header mismatch")
-	} else {
-		log.Println("...done")
+	if autoFixVal != nil {
+		Options.AutoFix = *autoFixVal
 	}
 
-	// 0008 4 Total data entry (next 0x10) in hex
-	totalDataEntries, err = readUint32(f)
-	if err != nil {
-		return err
+	if autoPitchVal != nil {
+		Options.AutoPitch = *autoPitchVal
+	}
+
+	if multiContainerVal != nil {
+		Options.MultiContainer = *multiContainerVal
+	}
+
+	if scanModeVal != nil {
+		Options.ScanMode = *scanModeVal
+	}
+
+	if spreadVal != nil {
+		Options.Spread = *spreadVal
+	}
+
+	if spreadStartVal != nil {
+		Options.SpreadStart = *spreadStartVal
+	}
+
+	if spreadRTLVal != nil {
+		Options.SpreadRightToLeft = *spreadRTLVal
+	}
+
+	if splitChannelsVal != nil {
+		Options.SplitChannels = *splitChannelsVal
+	}
+
+	if whiteToAlphaVal != nil {
+		Options.WhiteToAlpha = *whiteToAlphaVal
+	}
+
+	if whiteThresholdVal != nil {
+		Options.WhiteThreshold = *whiteThresholdVal
+	}
+
+	if spreadGutterVal != nil {
+		Options.SpreadGutter = *spreadGutterVal
+	}
+
+	if dpiVal != nil {
+		Options.DPI = *dpiVal
+	}
+
+	if dualNamesVal != nil {
+		Options.DualTileNames = *dualNamesVal
+	}
+
+	if dumpBlocksVal != nil {
+		Options.DumpBlocks = *dumpBlocksVal
+	}
+
+	if dumpDatabaseViewerVal != nil {
+		Options.DumpDatabaseViewer = *dumpDatabaseViewerVal
+	}
+
+	if dedupVal != nil {
+		Options.DedupTiles = *dedupVal
+	}
+
+	if diffVal != nil {
+		DiffPath = *diffVal
+	}
+
+	if estimateVal != nil {
+		Estimate = *estimateVal
+	}
+
+	if layoutVal != nil {
+		DumpLayout = *layoutVal
 	}
-	log.Printf("Number of Pages: %v", totalDataEntries)
 
-	// 000C 4 Total Length first part/start second part (first image id.gvd)
-	totalLengthFirstPart, err = readUint32(f)
+	err := Options.Validate()
 	if err != nil {
-		return err
+		failUsage("%v", err)
+	}
+
+	// Output of the images other than a local folder (used by -out with a URL or a .cbz file).
+	var output playview.Output
+	if playview.IsArchiveOutput(Options.OutDir) && Options.Resume {
+		// The archive is created anew, the pages completed by the earlier run would be missing from it.
+		failUsage("an archive output cannot be resumed")
 	}
-	if LogDebug {
-		log.Printf("totalLengthFirstPart: %v", totalLengthFirstPart)
+	if strings.Contains(Options.OutDir, "://") || playview.IsArchiveOutput(Options.OutDir) {
+		outLocation := Options.OutDir
+		var err error
+		output, err = playview.OpenOutput(Options.OutDir)
+		if err != nil {
+			failUsage("%v", err)
+		}
+		if fileOutput, isFile := output.(playview.FileOutput); isFile {
+			// Everything is written into the folder as usual.
+			Options.OutDir = fileOutput.Dir
+		} else {
+			if Options.RawPixels {
+				failUsage("pixel dumps can only be written into a local folder")
+			}
+			// Only the images go through the output, everything else stays in a local folder, next to an archive in
+			// the folder of the same name (book/ for book.cbz).
+			Options.Sink = playview.WriterSink(output, Options.DPI)
+			Options.OutDir = "out"
+			if playview.IsArchiveOutput(outLocation) {
+				Options.OutDir = strings.TrimSuffix(outLocation, filepath.Ext(outLocation))
+			}
+		}
+	}
+
+	if Options.ThumbsOnly {
+		// The thumbnail is a single tile, saved as it is.
+		Options.MergeImages = false
 	}
 
-	pages = make([]PageInfo, totalDataEntries)
+	if Options.ZoomAnimation {
+		// Every layer is merged on its own and becomes a frame.
+		Options.TargetLayer = -1
+		Options.SeparateLayers = true
+	}
+
+	if Options.AlignLayers {
+		// Every layer is merged on its own and scaled to the same size.
+		Options.TargetLayer = -1
+		Options.SeparateLayers = true
+	}
 
-	// 0020 xx Repeat for pages
-	for i := int(0); i < totalDataEntries; i++ {
+	// Number the output across all input files, or to keep the order by name (like in an archive).
+	Options.NumberPages = len(FilePaths) > 1 || Options.Order == "name" || playview.IsArchiveOutput(*outDirVal)
 
-		// 0010 4 Offset file name.gvd (without header TGDT0100)
-		pages[i].offsetFileName, err = readUint32(f)
+	// Start application.
+	if DiffPath != "" {
+		// Only compare the files.
+		if len(FilePaths) != 1 {
+			failUsage("only a single input file can be compared")
+		}
+		differences, err := diffFiles(FilePaths[0], DiffPath)
 		if err != nil {
-			return err
+			fail("unable to compare files: %v", err)
+		}
+		log.Printf(" >> %v differences found.", differences)
+		if differences > 0 {
+			exit(exitDifferent)
 		}
+		return
+	}
 
-		// 0014 4 Length file name.gvd (00 is not counted)
-		pages[i].lengthFileName, err = readUint32(f)
+	if Estimate {
+		// Only project the size of the output.
+		err := logEstimate(FilePaths)
 		if err != nil {
-			return err
+			fail("unable to estimate output: %v", err)
 		}
+		return
+	}
 
-		// 0018 4 Offset Data Base Viewer
-		pages[i].offsetDataBaseViewer, err = readUint32(f)
+	if DumpLayout {
+		// Only describe the fields of the file.
+		if len(FilePaths) != 1 {
+			failUsage("only the layout of a single input file can be printed")
+		}
+		err := printLayout(FilePaths[0])
 		if err != nil {
-			return err
+			fail("unable to describe layout: %v", err)
 		}
+		return
+	}
 
-		// 001C 4 Length Data base Viewer file
-		pages[i].lengthDataBaseViewer, err = readUint32(f)
+	if ServeAddr != "" {
+		// Render pages on request.
+		if len(FilePaths) != 1 {
+			failUsage("only a single input file can be served")
+		}
+		err := serve(ServeAddr, FilePaths[0])
 		if err != nil {
-			return err
+			fail("unable to serve: %v", err)
 		}
+		return
+	}
 
-		if LogDebug {
-			log.Printf("[Page %v] offsetFileName: %v", i, pages[i].offsetFileName)
-			log.Printf("[Page %v] lengthFileName: %v", i, pages[i].lengthFileName)
-			log.Printf("[Page %v] offsetDataBaseViewer: %v", i, pages[i].offsetDataBaseViewer)
-			log.Printf("[Page %v] lengthDataBaseViewer: %v", i, pages[i].lengthDataBaseViewer)
+	if !Options.ValidateOnly {
+		err := createOutDir(Options.OutDir)
+		if err != nil {
+			fail("%v", err)
 		}
 	}
 
-	// 0XXX xx Filled with 00 until the first image ID.gvd start
+	// Hooks for every merged page.
+	var pageHooks []func(pageName string, img image.Image)
+	if ContactSheetPath != "" {
+		// Keep a thumbnail of every merged page.
+		pageHooks = append(pageHooks, addThumbnail)
+	}
+	if ReferenceDir != "" {
+		// Check every merged page against the earlier run.
+		pageHooks = append(pageHooks, compareReference)
+	}
+	if len(pageHooks) > 0 {
+		Options.OnPage = func(pageName string, img image.Image) {
+			for _, hook := range pageHooks {
+				hook(pageName, img)
+			}
+		}
+	}
 
-	log.Printf(" >> Header done.")
+	extractor := playview.New(Options)
+	started := time.Now()
 
-	return nil
+	// A failed file does not stop the others.
+	failedFiles := 0
+	if Jobs > 1 && len(FilePaths) > 1 {
+		// Extract the books concurrently, each into its own folder.
+		extractor.Stats, failedFiles = extractParallel(FilePaths, Jobs)
+	} else {
+		for _, filePath := range FilePaths {
+			err := extractor.ExtractFile(filePath)
+			if err != nil {
+				logExtractionError(err)
+				failedFiles++
+			}
+		}
+	}
+	if ExtractionInfo && !Options.ValidateOnly {
+		err := saveExtractionInfo(output, started, FilePaths, extractor.Stats)
+		if err != nil {
+			fail("unable to write extraction info: %v", err)
+		}
+	}
+	if closer, isCloser := output.(io.Closer); isCloser {
+		err := closer.Close()
+		if err != nil {
+			fail("%v", err)
+		}
+	}
+
+	if failedFiles == len(FilePaths) {
+		exit(exitFailed)
+	}
+
+	if ContactSheetPath != "" && !Options.ValidateOnly {
+		err := writeContactSheet(ContactSheetPath, ContactSheetColumns)
+		if err != nil {
+			fail("unable to write contact sheet: %v", err)
+		}
+	}
+
+	if ContentPath != "" {
+		err := extractor.CheckContentPages(ContentPath)
+		if err != nil {
+			fail("unable to check content: %v", err)
+		}
+	}
+
+	stats := extractor.Stats
+	if Options.ValidateOnly {
+		logProgress(" >> Decodable tiles: %v, undecodable tiles: %v, warnings: %v", stats.DecodedTiles, stats.FailedTiles, stats.Warnings)
+	}
+
+	if crashed := stats.CrashedPages; len(crashed) > 0 {
+		log.Printf(" >> %v pages crashed: %v", len(crashed), strings.Join(crashed, ", "))
+	}
+
+	if failedFiles > 0 {
+		log.Printf(" >> %v of %v files failed.", failedFiles, len(FilePaths))
+	}
+	if stats.FailedPages > 0 {
+		log.Printf(" >> %v pages failed.", stats.FailedPages)
+	}
+	if len(referenceFailures) > 0 {
+		log.Printf(" >> %v pages differ from the reference: %v", len(referenceFailures), strings.Join(referenceFailures, ", "))
+		exit(exitDifferent)
+	}
+	if failedFiles > 0 || stats.FailedPages > 0 {
+		exit(exitPartial)
+	}
+	if Strict && stats.Warnings > 0 {
+		log.Printf(" >> %v warnings, failing because of -strict.", stats.Warnings)
+		exit(exitPartial)
+	}
+
+	logProgress("done")
+	exit(exitComplete)
 }
 
-func readBytes(f *os.File, len int) ([]byte, error) {
-	str := make([]byte, len)
-	_, err := f.Read(str)
+// parsePair parses two comma separated integers like "12,-4".
+func parsePair(value string) (int, int, error) {
+	first, second, found := strings.Cut(value, ",")
+	if !found {
+		return 0, 0, fmt.Errorf("expected X,Y but got %v", value)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(first))
 	if err != nil {
-		return []byte(""), err
+		return 0, 0, err
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(second))
+	if err != nil {
+		return 0, 0, err
 	}
-	return str, nil
+	return x, y, nil
 }
 
-func readString(f *os.File, len int) (string, error) {
-	raw, err := readBytes(f, len)
-	return string(raw), err
-	// Simulate a null terminated string.
-	// return string(raw[:clen(raw)]), err
+// parseRect parses a region like "100,200,640,480" given as X,Y,W,H.
+func parseRect(value string) (image.Rectangle, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return image.Rectangle{}, fmt.Errorf("expected X,Y,W,H but got %v", value)
+	}
+	var numbers [4]int
+	for n, field := range fields {
+		number, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		numbers[n] = number
+	}
+	if numbers[2] <= 0 || numbers[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("expected a positive size but got %v", value)
+	}
+	return image.Rect(numbers[0], numbers[1], numbers[0]+numbers[2], numbers[1]+numbers[3]), nil
 }
 
-func readUint32(f *os.File) (int, error) {
-	raw, err := readBytes(f, 4)
+// parseSize parses a size like "1072x1448".
+func parseSize(value string) (int, int, error) {
+	first, second, found := strings.Cut(strings.ToLower(value), "x")
+	if !found {
+		return 0, 0, fmt.Errorf("expected WxH but got %v", value)
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(second))
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
-	return int(binary.BigEndian.Uint32(raw)), nil
+	if width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("expected a positive size but got %v", value)
+	}
+	return width, height, nil
 }
 
-func readUint4(f *os.File) (int, int, error) {
-	raw, err := readBytes(f, 1)
+// parseColor parses a hex color like "ffffff" or "#1e1e1e".
+func parseColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("expected RRGGBB but got %v", value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}, nil
+}
 
-	rawByte := int(raw[0])
-	upper := rawByte >> 4
-	lower := rawByte & 0x0F
+// failUsage reports an invalid command line and exits.
+func failUsage(format string, v ...any) {
+	fmt.Fprintf(flag.CommandLine.Output(), format+"\n", v...)
+	exit(exitUsage)
+}
 
-	return int(upper), int(lower), nil
+// fail logs why the run could not be completed and exits.
+func fail(format string, v ...any) {
+	log.Printf(" >> "+format, v...)
+	exit(exitFailed)
 }
 
-func clen(n []byte) int {
-	for i := 0; i < len(n); i++ {
-		if n[i] == 0 {
-			return i
+// logProgress logs the progress, unless only warnings and errors are logged.
+func logProgress(format string, v ...any) {
+	if Options.LogLevel > playview.LogQuiet {
+		log.Printf(format, v...)
+	}
+}
+
+// logExtractionError reports why a file could not be extracted.
+func logExtractionError(err error) {
+	var headerErr *playview.HeaderError
+	if errors.As(err, &headerErr) {
+		log.Printf(" >> This doesn't look like a PlayView gvd.dat (header was %q), try -force to parse it anyway.", headerErr.Header)
+		return
+	}
+	log.Printf(" >> Extraction failed: %v", err)
+}
+
+// printLayout writes the layout of the header and the first page of a file to stdout as JSON.
+func printLayout(filePath string) error {
+	layout, err := playview.New(Options).DescribeLayout(filePath)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(encoded))
+	return err
+}
+
+// createOutDir creates the output folder (and its parents) unless it already exists.
+func createOutDir(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		if info, statErr := os.Stat(dir); statErr == nil && !info.IsDir() {
+			return fmt.Errorf("output path %v exists but is not a directory", dir)
 		}
+		return fmt.Errorf("unable to create output folder: %v", err)
 	}
-	return len(n)
+	return nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		width   int
+		height  int
+		wantErr bool
+	}{
+		{value: "1072x1448", width: 1072, height: 1448},
+		{value: "800X600", width: 800, height: 600},
+		{value: " 640 x 480 ", width: 640, height: 480},
+		{value: "1072", wantErr: true},
+		{value: "0x100", wantErr: true},
+		{value: "100x-1", wantErr: true},
+		{value: "axb", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, test := range tests {
+		width, height, err := parseSize(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseSize(%q) = %v, %v, expected an error", test.value, width, height)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSize(%q) failed: %v", test.value, err)
+			continue
+		}
+		if width != test.width || height != test.height {
+			t.Errorf("parseSize(%q) = %vx%v, expected %vx%v", test.value, width, height, test.width, test.height)
+		}
+	}
+}
+
+func TestParseRect(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{value: "100,200,640,480", want: image.Rect(100, 200, 740, 680)},
+		{value: "0, 0, 300, 300", want: image.Rect(0, 0, 300, 300)},
+		{value: "-10,-20,30,40", want: image.Rect(-10, -20, 20, 20)},
+		{value: "1,2,3", wantErr: true},
+		{value: "1,2,3,4,5", wantErr: true},
+		{value: "1,2,0,4", wantErr: true},
+		{value: "1,2,3,-4", wantErr: true},
+		{value: "a,2,3,4", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseRect(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseRect(%q) = %v, expected an error", test.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRect(%q) failed: %v", test.value, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseRect(%q) = %v, expected %v", test.value, got, test.want)
+		}
+	}
+}
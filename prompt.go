@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Set when the extractor was started without flags and asked for the input, e.g. after a double click on Windows. The
+// console window closes with the program, so it waits for Enter before exiting.
+var pauseOnExit bool
+
+// exit ends the program with the given exit code.
+func exit(code int) {
+	if pauseOnExit {
+		fmt.Print("Press Enter to close this window.")
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+	os.Exit(code)
+}
+
+// findPlayViewFiles lists the files *.dat in a folder that start with the TGDT0100 header.
+func findPlayViewFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.dat"))
+	var filePaths []string
+	for _, match := range matches {
+		handle, err := os.Open(match)
+		if err != nil {
+			continue
+		}
+		header := make([]byte, 8)
+		_, err = io.ReadFull(handle, header)
+		_ = handle.Close()
+		if err == nil && string(header) == "TGDT0100" {
+			filePaths = append(filePaths, match)
+		}
+	}
+	slices.Sort(filePaths)
+	return filePaths
+}
+
+// promptForInput explains what to do when there is no gvd.dat in the current folder and offers to extract one of the
+// PlayView files found there instead. It returns false if there is none or none was chosen.
+func promptForInput(in io.Reader, out io.Writer, dir string) (string, bool) {
+	fmt.Fprintln(out, "There is no gvd.dat in this folder.")
+	fmt.Fprintln(out, "Copy the gvd.dat of your book next to this program, or run it with -in <path> (see -help).")
+	fmt.Fprintln(out)
+
+	filePaths := findPlayViewFiles(dir)
+	if len(filePaths) == 0 {
+		fmt.Fprintln(out, "No other PlayView files (*.dat) were found here either.")
+		return "", false
+	}
+
+	fmt.Fprintln(out, "These PlayView files were found here:")
+	for n, filePath := range filePaths {
+		fmt.Fprintf(out, "  [%v] %v\n", n+1, filepath.Base(filePath))
+	}
+	if len(filePaths) == 1 {
+		fmt.Fprintf(out, "Extract %v into the folder \"out\"? [Y/n] ", filepath.Base(filePaths[0]))
+	} else {
+		fmt.Fprintf(out, "Number of the file to extract into the folder \"out\" (empty to cancel): ")
+	}
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if len(filePaths) == 1 {
+		if answer == "" || answer == "y" || answer == "yes" {
+			return filePaths[0], true
+		}
+		return "", false
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(filePaths) {
+		return "", false
+	}
+	return filePaths[n-1], true
+}
+
+// askForInput asks on the console which file to extract, see promptForInput.
+func askForInput() (string, bool) {
+	pauseOnExit = runtime.GOOS == "windows"
+	return promptForInput(os.Stdin, os.Stdout, ".")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptForInput(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("unable to write %v: %v", name, err)
+		}
+	}
+
+	if _, found := promptForInput(strings.NewReader("\n"), io.Discard, dir); found {
+		t.Errorf("a file was chosen in an empty folder")
+	}
+
+	write("book.dat", "TGDT0100 book")
+	write("other.dat", "not a book")
+	write("notes.txt", "TGDT0100 not a dat")
+	for _, answer := range []string{"\n", "y\n", "Yes\n", ""} {
+		filePath, found := promptForInput(strings.NewReader(answer), io.Discard, dir)
+		if !found || filepath.Base(filePath) != "book.dat" {
+			t.Errorf("answer %q chose %q (%v), expected book.dat", answer, filePath, found)
+		}
+	}
+	if _, found := promptForInput(strings.NewReader("n\n"), io.Discard, dir); found {
+		t.Errorf("a file was chosen after answering no")
+	}
+
+	write("another.dat", "TGDT0100 second book")
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{answer: "1\n", want: "another.dat"},
+		{answer: "2\n", want: "book.dat"},
+		{answer: "3\n", want: ""},
+		{answer: "\n", want: ""},
+	}
+	for _, test := range tests {
+		filePath, found := promptForInput(strings.NewReader(test.answer), io.Discard, dir)
+		if found != (test.want != "") || (found && filepath.Base(filePath) != test.want) {
+			t.Errorf("answer %q chose %q (%v), expected %q", test.answer, filePath, found, test.want)
+		}
+	}
+}
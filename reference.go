@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path"
+)
+
+// Pages that differ from their reference, or have none (used by -reference).
+var referenceFailures []string
+
+// compareReference compares a merged page with <ReferenceDir>/<name>.png, as written by an earlier run with the same
+// options, and logs the maximum and mean difference of the color channels.
+func compareReference(name string, img image.Image) {
+	referencePath := path.Join(ReferenceDir, fmt.Sprintf("%v.png", name))
+	reference, err := readPNG(referencePath)
+	if err != nil {
+		log.Printf("  [REFERENCE] [%v] %v", name, err)
+		referenceFailures = append(referenceFailures, name)
+		return
+	}
+
+	maxDiff, meanDiff, err := imageDifference(img, reference)
+	if err != nil {
+		log.Printf("  [REFERENCE] [%v] %v", name, err)
+		referenceFailures = append(referenceFailures, name)
+		return
+	}
+
+	logProgress("  [REFERENCE] [%v] max difference %v, mean %.3f", name, maxDiff, meanDiff)
+	if maxDiff > ReferenceThreshold {
+		referenceFailures = append(referenceFailures, name)
+	}
+}
+
+// readPNG decodes the png at filePath.
+func readPNG(filePath string) (image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open reference: %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode reference: %v", err)
+	}
+	return img, nil
+}
+
+// imageDifference returns the maximum and the mean difference of the 8-bit RGBA channels of two images of the same size.
+func imageDifference(a image.Image, b image.Image) (int, float64, error) {
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if boundsA.Size() != boundsB.Size() {
+		return 0, 0, fmt.Errorf("size %vx%v differs from the reference %vx%v", boundsA.Dx(), boundsA.Dy(), boundsB.Dx(), boundsB.Dy())
+	}
+
+	maxDiff, sum := 0, 0
+	for y := 0; y < boundsA.Dy(); y++ {
+		for x := 0; x < boundsA.Dx(); x++ {
+			r1, g1, b1, a1 := a.At(boundsA.Min.X+x, boundsA.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(boundsB.Min.X+x, boundsB.Min.Y+y).RGBA()
+			for _, channel := range [][2]uint32{{r1, r2}, {g1, g2}, {b1, b2}, {a1, a2}} {
+				diff := abs(int(channel[0]>>8) - int(channel[1]>>8))
+				maxDiff = max(maxDiff, diff)
+				sum += diff
+			}
+		}
+	}
+
+	channels := boundsA.Dx() * boundsA.Dy() * 4
+	if channels == 0 {
+		return 0, 0, nil
+	}
+	return maxDiff, float64(sum) / float64(channels), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
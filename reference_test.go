@@ -0,0 +1,31 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestImageDifference(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	b := image.NewRGBA(image.Rect(5, 5, 7, 7))
+	for n := range a.Pix {
+		a.Pix[n], b.Pix[n] = 0x80, 0x80
+	}
+
+	maxDiff, meanDiff, err := imageDifference(a, b)
+	if err != nil || maxDiff != 0 || meanDiff != 0 {
+		t.Errorf("equal images: max %v, mean %v, error %v", maxDiff, meanDiff, err)
+	}
+
+	b.SetRGBA(6, 6, color.RGBA{R: 0x88, G: 0x80, B: 0x80, A: 0x80})
+	maxDiff, meanDiff, err = imageDifference(a, b)
+	if err != nil || maxDiff != 8 || meanDiff != 0.5 {
+		t.Errorf("one channel off by 8: max %v, mean %v, error %v", maxDiff, meanDiff, err)
+	}
+
+	_, _, err = imageDifference(a, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	if err == nil {
+		t.Errorf("compared images of different sizes")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/joernlenoch/playview-extractor/internal/playview"
+)
+
+// serve exposes the merged pages of a file at /page/<name>.png. Pages are rendered on first request and cached.
+func serve(addr string, filePath string) error {
+	// RenderPage always merges and leaves undecodable tiles empty.
+	f, err := playview.New(Options).Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// The parser works on a single file handle, so pages are rendered one at a time.
+	var lock sync.Mutex
+	cache := map[string][]byte{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /page/{file}", func(w http.ResponseWriter, r *http.Request) {
+		name, isPNG := strings.CutSuffix(r.PathValue("file"), ".png")
+		if !isPNG {
+			http.NotFound(w, r)
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		data, cached := cache[name]
+		if !cached {
+			if f.FindPage(name) == -1 {
+				http.NotFound(w, r)
+				return
+			}
+
+			log.Printf("  > Render [%v]", name)
+
+			rendered, err := f.RenderPage(name)
+			if errors.Is(err, playview.ErrNoImage) {
+				http.Error(w, "page has no image data", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				log.Printf("  [ERROR] Unable to render page %v: %v", name, err)
+				http.Error(w, "unable to render page", http.StatusInternalServerError)
+				return
+			}
+
+			var buf bytes.Buffer
+			err = png.Encode(&buf, rendered)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to encode png: %v", err), http.StatusInternalServerError)
+				return
+			}
+			data = buf.Bytes()
+			cache[name] = data
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(data)
+	})
+
+	log.Printf(" >> Serving %v pages at %v", len(f.Pages), addr)
+
+	return http.ListenAndServe(addr, mux)
+}